@@ -0,0 +1,120 @@
+package responseutils
+
+import (
+	"net/http"
+	"time"
+)
+
+// ErrCodeAccountLocked is ErrUserAccountLocked under the ErrCode naming used
+// by the rest of the account-state family
+const ErrCodeAccountLocked = ErrUserAccountLocked
+
+// Sentinel account-state errors for branching with errors.Is
+var (
+	ErrAccountSuspended = NewResponseError(ErrCodeAccountSuspended, "Account suspended", http.StatusForbidden).Freeze()
+	ErrEmailUnverified  = NewResponseError(ErrCodeEmailUnverified, "Email address not verified", http.StatusForbidden).Freeze()
+	ErrPasswordExpired  = NewResponseError(ErrCodePasswordExpired, "Password expired", http.StatusForbidden).Freeze()
+	ErrMFARequired      = NewResponseError(ErrCodeMFARequired, "Multi-factor authentication required", http.StatusUnauthorized).Freeze()
+)
+
+// RemediationKey is the details key holding the remediation hint of account-state errors
+const RemediationKey = "remediation"
+
+// Remediation tells the client how the user can resolve an account-state error
+// @Description Account remediation hint structure
+type Remediation struct {
+	Action string `json:"action" example:"verify_email"`
+	URL    string `json:"url,omitempty" example:"https://example.com/verify"`
+}
+
+// Remediation actions
+const (
+	RemediationWait           = "wait"
+	RemediationUnlock         = "unlock_account"
+	RemediationContactSupport = "contact_support"
+	RemediationVerifyEmail    = "verify_email"
+	RemediationChangePassword = "change_password"
+	RemediationCompleteMFA    = "complete_mfa"
+)
+
+// AccountLocked creates a 403 error for a temporarily locked account. A zero
+// until means the lock has no scheduled end; unlockURL may be empty.
+func AccountLocked(until time.Time, unlockURL string) *ResponseError {
+	e := NewResponseError(ErrCodeAccountLocked, "Account locked", http.StatusForbidden)
+	action := RemediationUnlock
+	if !until.IsZero() {
+		e.WithDetails("locked_until", until.UTC())
+		if unlockURL == "" {
+			action = RemediationWait
+		}
+	} else if unlockURL == "" {
+		action = RemediationContactSupport
+	}
+	return e.WithDetails(RemediationKey, Remediation{Action: action, URL: unlockURL})
+}
+
+// AccountSuspended creates a 403 error for an account suspended by an
+// administrator, pointing the user to support or an appeal form
+func AccountSuspended(reason string, appealURL string) *ResponseError {
+	e := NewResponseError(ErrCodeAccountSuspended, "Account suspended", http.StatusForbidden)
+	if reason != "" {
+		e.WithDetails("reason", reason)
+	}
+	return e.WithDetails(RemediationKey, Remediation{Action: RemediationContactSupport, URL: appealURL})
+}
+
+// EmailUnverified creates a 403 error for an account whose email address
+// must be verified first
+func EmailUnverified(verificationURL string) *ResponseError {
+	return NewResponseError(ErrCodeEmailUnverified, "Email address not verified", http.StatusForbidden).
+		WithDetails(RemediationKey, Remediation{Action: RemediationVerifyEmail, URL: verificationURL})
+}
+
+// PasswordExpired creates a 403 error for an account whose password must be changed
+func PasswordExpired(changeURL string) *ResponseError {
+	return NewResponseError(ErrCodePasswordExpired, "Password expired", http.StatusForbidden).
+		WithDetails(RemediationKey, Remediation{Action: RemediationChangePassword, URL: changeURL})
+}
+
+// MFARequired creates a 401 error asking for a second factor, listing the
+// methods available to the user, e.g. "totp" or "webauthn"
+func MFARequired(methods ...string) *ResponseError {
+	if methods == nil {
+		methods = []string{}
+	}
+	return NewResponseError(ErrCodeMFARequired, "Multi-factor authentication required", http.StatusUnauthorized).
+		WithDetails("methods", methods).
+		WithDetails(RemediationKey, Remediation{Action: RemediationCompleteMFA})
+}
+
+// ChallengeKey is the details key holding step-up authentication and bot challenges
+const ChallengeKey = "challenge"
+
+// StepUpChallenge describes a step-up authentication challenge the client
+// must complete before retrying the request
+// @Description Step-up authentication challenge structure
+type StepUpChallenge struct {
+	ID        string    `json:"id" example:"chl_7f3a9c"`
+	Methods   []string  `json:"methods" example:"totp,webauthn"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// ACR is the authentication context class the request requires, if any
+	ACR string `json:"acr,omitempty" example:"urn:example:mfa"`
+}
+
+// StepUpRequired creates a 401 MFA_REQUIRED error carrying a step-up
+// challenge under details.challenge. When an ACR is given, the RFC 9470
+// insufficient_user_authentication WWW-Authenticate challenge is sent.
+func StepUpRequired(challenge StepUpChallenge) *ResponseError {
+	if challenge.Methods == nil {
+		challenge.Methods = []string{}
+	}
+
+	e := NewResponseError(ErrCodeMFARequired, "Additional authentication required", http.StatusUnauthorized).
+		WithDetails("methods", challenge.Methods).
+		WithDetails(RemediationKey, Remediation{Action: RemediationCompleteMFA}).
+		WithDetails(ChallengeKey, challenge)
+	if challenge.ACR != "" {
+		e.WithHeader("WWW-Authenticate", `Bearer error="insufficient_user_authentication", acr_values="`+challenge.ACR+`"`)
+	}
+	return e
+}
@@ -0,0 +1,59 @@
+package responseutils
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigPath is the conventional route for the runtime configuration handler
+const ConfigPath = "/internal/response-config"
+
+// RuntimeConfig describes the package's current configuration
+type RuntimeConfig struct {
+	Encoder        string            `json:"encoder"`
+	EncodingPolicy *EncodingPolicy   `json:"encoding_policy,omitempty"`
+	Debug          bool              `json:"debug"`
+	Negotiation    NegotiationPolicy `json:"negotiation_policy"`
+	IDKind         IDKind            `json:"id_kind"`
+	FlagProvider   string            `json:"flag_provider,omitempty"`
+	ResponseHooks  int               `json:"response_hooks"`
+	ErrorCodes     []ErrorCodeInfo   `json:"error_codes"`
+}
+
+// CurrentConfig returns the package's current configuration
+func CurrentConfig() RuntimeConfig {
+	enc := CurrentEncoder()
+	cfg := RuntimeConfig{
+		Encoder:     fmt.Sprintf("%T", enc),
+		Debug:       DebugEnabled(),
+		Negotiation: CurrentNegotiationPolicy(),
+		IDKind:      CurrentIDKind(),
+		ErrorCodes:  ErrorCodes(),
+	}
+
+	if pe, ok := enc.(*PolicyEncoder); ok {
+		policy := pe.Policy()
+		cfg.EncodingPolicy = &policy
+	}
+
+	flagsMu.RLock()
+	if flagProvider != nil {
+		cfg.FlagProvider = fmt.Sprintf("%T", flagProvider)
+	}
+	flagsMu.RUnlock()
+
+	hooksMu.RLock()
+	cfg.ResponseHooks = len(responseHooks)
+	hooksMu.RUnlock()
+
+	return cfg
+}
+
+// ConfigHandler sends the package's current configuration, for operators
+// debugging why two environments behave differently
+func ConfigHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		OKResponse(c, CurrentConfig(), "Response configuration retrieved successfully")
+	}
+}
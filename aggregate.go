@@ -0,0 +1,87 @@
+package responseutils
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AggregatePolicy determines the overall status of an aggregated response
+type AggregatePolicy int
+
+const (
+	// AllOrNothing fails the whole response when any source fails
+	AllOrNothing AggregatePolicy = iota
+	// BestEffort succeeds when at least one source succeeds, marking the response degraded
+	BestEffort
+)
+
+// Result is the outcome of a single fan-out call
+type Result struct {
+	Data interface{}
+	Err  error
+}
+
+// SourceResult represents the outcome of a single source in an aggregated response
+type SourceResult struct {
+	Success bool                   `json:"success"`
+	Data    interface{}            `json:"data,omitempty"`
+	Error   map[string]interface{} `json:"error,omitempty"`
+}
+
+// AggregateResponseDTO represents a fan-out response with per-source results
+// @Description Aggregated response structure
+type AggregateResponseDTO struct {
+	Success     bool                    `json:"success" example:"true"`
+	Data        map[string]SourceResult `json:"data"`
+	Degraded    bool                    `json:"degraded,omitempty"`
+	Unavailable []string                `json:"unavailable,omitempty"`
+	Message     string                  `json:"message,omitempty"`
+}
+
+// AggregateResponses sends the per-source results of a fan-out in a single envelope.
+// Failed sources are reported with their error detail; the overall status is computed
+// from the policy, using the highest failing status code when the response fails.
+func AggregateResponses(c *gin.Context, results map[string]Result, policy AggregatePolicy) {
+	statusCode, body := BuildAggregate(results, policy)
+	writeJSON(c, statusCode, body)
+}
+
+// BuildAggregate computes the status code and envelope for a set of fan-out results
+func BuildAggregate(results map[string]Result, policy AggregatePolicy) (int, AggregateResponseDTO) {
+	body := AggregateResponseDTO{
+		Success: true,
+		Data:    make(map[string]SourceResult, len(results)),
+	}
+
+	failedStatus := 0
+	for name, result := range results {
+		if result.Err == nil {
+			body.Data[name] = SourceResult{Success: true, Data: result.Data}
+			continue
+		}
+
+		statusCode, errBody := errorBody(result.Err)
+		if statusCode > failedStatus {
+			failedStatus = statusCode
+		}
+		body.Data[name] = SourceResult{Success: false, Error: errBody}
+		body.Unavailable = append(body.Unavailable, name)
+	}
+	sort.Strings(body.Unavailable)
+
+	failed := len(body.Unavailable)
+	switch {
+	case failed == 0:
+		return http.StatusOK, body
+	case policy == BestEffort && failed < len(results):
+		body.Degraded = true
+		body.Message = "Some components are unavailable"
+		return http.StatusOK, body
+	default:
+		body.Success = false
+		body.Message = "One or more components failed"
+		return failedStatus, body
+	}
+}
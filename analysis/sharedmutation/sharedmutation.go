@@ -0,0 +1,142 @@
+// Package sharedmutation defines an analyzer that reports mutation of
+// package-level *responseutils.ResponseError values which have not been frozen.
+//
+// Reusing such a value across requests and calling WithDetails on it mutates
+// a shared details map, which races under concurrent requests.
+package sharedmutation
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const responseUtilsPath = "github.com/geekible-ltd/response-utils"
+
+// Analyzer reports WithDetails calls and Details writes on unfrozen package-level errors
+var Analyzer = &analysis.Analyzer{
+	Name:      "sharedmutation",
+	Doc:       "report mutation of shared, unfrozen *ResponseError package-level variables",
+	Run:       run,
+	FactTypes: []analysis.Fact{new(frozenFact)},
+}
+
+// frozenFact marks a package-level variable initialised with a Freeze() call
+type frozenFact struct{}
+
+func (*frozenFact) AFact() {}
+
+func (*frozenFact) String() string { return "frozen" }
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, v := range frozenVars(pass) {
+		pass.ExportObjectFact(v, new(frozenFact))
+	}
+
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.CallExpr:
+				sel, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "WithDetails" {
+					return true
+				}
+				if v := sharedErrorVar(pass, sel.X); v != nil && !pass.ImportObjectFact(v, new(frozenFact)) {
+					pass.Reportf(node.Pos(), "WithDetails on package-level %s mutates shared state; call Freeze() at declaration or Clone() first", v.Name())
+				}
+			case *ast.AssignStmt:
+				for _, lhs := range node.Lhs {
+					index, ok := lhs.(*ast.IndexExpr)
+					if !ok {
+						continue
+					}
+					sel, ok := index.X.(*ast.SelectorExpr)
+					if !ok || sel.Sel.Name != "Details" {
+						continue
+					}
+					if v := sharedErrorVar(pass, sel.X); v != nil {
+						pass.Reportf(lhs.Pos(), "write to Details of package-level %s mutates shared state; use Clone() first", v.Name())
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	return nil, nil
+}
+
+// sharedErrorVar returns the package-level *ResponseError variable referenced by expr, if any
+func sharedErrorVar(pass *analysis.Pass, expr ast.Expr) *types.Var {
+	var ident *ast.Ident
+	switch e := expr.(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return nil
+	}
+
+	v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil
+	}
+	if !isResponseError(v.Type()) {
+		return nil
+	}
+	return v
+}
+
+// frozenVars collects package-level variables of this package initialised with a Freeze() call
+func frozenVars(pass *analysis.Pass) []*types.Var {
+	var frozen []*types.Var
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) || !endsWithFreeze(vs.Values[i]) {
+						continue
+					}
+					if v, ok := pass.TypesInfo.Defs[name].(*types.Var); ok {
+						frozen = append(frozen, v)
+					}
+				}
+			}
+		}
+	}
+
+	return frozen
+}
+
+func endsWithFreeze(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Freeze"
+}
+
+func isResponseError(t types.Type) bool {
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "ResponseError" && obj.Pkg() != nil && obj.Pkg().Path() == responseUtilsPath
+}
@@ -0,0 +1,95 @@
+package responseutils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIIndexOptions configures the API root response
+type APIIndexOptions struct {
+	// Name of the API
+	Name string
+	// Version of the API; defaults to the build's module version
+	Version string
+	// MediaTypes supported by the API; defaults to application/json
+	MediaTypes []string
+	// ExcludePrefixes hides routes starting with any of the prefixes, e.g. "/internal"
+	ExcludePrefixes []string
+}
+
+// APIResource describes a registered route and its methods
+type APIResource struct {
+	Path    string   `json:"path" example:"/users/:id"`
+	Methods []string `json:"methods" example:"GET,PUT"`
+}
+
+// APIIndex is a self-describing API root response
+// @Description API index structure
+type APIIndex struct {
+	Name       string        `json:"name,omitempty" example:"users-api"`
+	Version    string        `json:"version,omitempty" example:"v1.4.2"`
+	MediaTypes []string      `json:"media_types" example:"application/json"`
+	Resources  []APIResource `json:"resources"`
+}
+
+// BuildAPIIndex describes the routes registered on an engine
+func BuildAPIIndex(engine *gin.Engine, opts APIIndexOptions) APIIndex {
+	index := APIIndex{
+		Name:       opts.Name,
+		Version:    opts.Version,
+		MediaTypes: opts.MediaTypes,
+		Resources:  []APIResource{},
+	}
+	if index.Version == "" {
+		index.Version = ReadBuildInfo().Version
+	}
+	if len(index.MediaTypes) == 0 {
+		index.MediaTypes = []string{"application/json"}
+	}
+
+	methods := map[string][]string{}
+	for _, route := range engine.Routes() {
+		if hasAnyPrefix(route.Path, opts.ExcludePrefixes) {
+			continue
+		}
+		methods[route.Path] = append(methods[route.Path], route.Method)
+	}
+
+	for path, m := range methods {
+		sort.Strings(m)
+		index.Resources = append(index.Resources, APIResource{Path: path, Methods: m})
+	}
+	sort.Slice(index.Resources, func(i, j int) bool {
+		return index.Resources[i].Path < index.Resources[j].Path
+	})
+
+	return index
+}
+
+// APIRootHandler returns a discovery handler describing the engine's routes,
+// with a self link and a link per resource
+func APIRootHandler(engine *gin.Engine, opts APIIndexOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		index := BuildAPIIndex(engine, opts)
+
+		AddLink(c, "self", c.Request.URL.Path)
+		for _, resource := range index.Resources {
+			for _, method := range resource.Methods {
+				AddLinks(c, Link{Rel: "resource", Href: resource.Path, Method: method})
+			}
+		}
+
+		OKResponse(c, index, "API index retrieved successfully")
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package responseutils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the default header carrying the API key
+const APIKeyHeader = "X-API-Key"
+
+// API keys have the form "<id>.<secret>". The ID is public and safe to log,
+// e.g. "live_3f9a2c"; only the SHA-256 of the secret is stored.
+var apiKeyPattern = regexp.MustCompile(`^([A-Za-z0-9_-]{1,64})\.([A-Za-z0-9_-]{16,256})$`)
+
+// APIKey is a stored API key
+type APIKey struct {
+	ID string
+	// SecretHash is the SHA-256 of the key's secret, see HashAPIKeySecret
+	SecretHash []byte
+	// Owner identifies who the key belongs to, e.g. an account ID
+	Owner  string
+	Scopes []string
+	// ExpiresAt is zero for keys that never expire
+	ExpiresAt time.Time
+	// RevokedAt is zero for keys that have not been revoked
+	RevokedAt time.Time
+}
+
+// APIKeyLookup finds a stored API key by its ID, returning nil for unknown IDs
+type APIKeyLookup interface {
+	LookupAPIKey(ctx context.Context, id string) (*APIKey, error)
+}
+
+// APIKeyLookupFunc adapts a function to the APIKeyLookup interface
+type APIKeyLookupFunc func(ctx context.Context, id string) (*APIKey, error)
+
+// LookupAPIKey implements APIKeyLookup
+func (f APIKeyLookupFunc) LookupAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	return f(ctx, id)
+}
+
+// APIKeyOptions configure APIKeyAuth
+type APIKeyOptions struct {
+	// Header carrying the key; defaults to X-API-Key
+	Header string
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+type apiKeyKey struct{}
+
+// ContextWithAPIKey returns a copy of ctx carrying an authenticated API key
+func ContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the API key authenticated by APIKeyAuth
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*APIKey)
+	return key, ok
+}
+
+// APIKeyScopes returns the scopes of the request's API key, for RequireScopes
+func APIKeyScopes(c *gin.Context) []string {
+	if key, ok := APIKeyFromContext(c.Request.Context()); ok {
+		return key.Scopes
+	}
+	return nil
+}
+
+// APIKeyAuth returns middleware authenticating requests by API key. The
+// key's ID is looked up and its secret compared in constant time; the
+// authenticated key is available from APIKeyFromContext and its ID is added
+// to access log entries. Failures answer 401 with MISSING_API_KEY,
+// MALFORMED_API_KEY, INVALID_API_KEY, REVOKED_API_KEY or EXPIRED_API_KEY;
+// error details only ever carry the key's ID.
+func APIKeyAuth(lookup APIKeyLookup, opts APIKeyOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = APIKeyHeader
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return func(c *gin.Context) {
+		key, err := authenticateAPIKey(c, lookup, opts)
+		if err != nil {
+			ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(ContextWithAPIKey(c.Request.Context(), key))
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, lookup APIKeyLookup, opts APIKeyOptions) (*APIKey, error) {
+	raw := strings.TrimSpace(c.GetHeader(opts.Header))
+	if raw == "" {
+		return nil, MissingAPIKey(opts.Header)
+	}
+	m := apiKeyPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return nil, MalformedAPIKey()
+	}
+	id, secret := m[1], m[2]
+
+	key, err := lookup.LookupAPIKey(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	// compare against a dummy hash for unknown IDs so timing does not reveal
+	// which IDs exist
+	want := make([]byte, sha256.Size)
+	if key != nil {
+		want = key.SecretHash
+	}
+	if subtle.ConstantTimeCompare(HashAPIKeySecret(secret), want) != 1 || key == nil {
+		return nil, InvalidAPIKey(id)
+	}
+
+	now := opts.Now()
+	if !key.RevokedAt.IsZero() && !now.Before(key.RevokedAt) {
+		return nil, RevokedAPIKey(id, key.RevokedAt)
+	}
+	if !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt) {
+		return nil, ExpiredAPIKey(id, key.ExpiresAt)
+	}
+	return key, nil
+}
+
+// HashAPIKeySecret returns the SHA-256 of a key's secret, as stored in
+// APIKey.SecretHash
+func HashAPIKeySecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// GenerateAPIKey creates a key for id with a random 256-bit secret. Show the
+// key to its owner once and store only the returned hash.
+func GenerateAPIKey(id string) (key string, secretHash []byte, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	key = id + "." + secret
+	if !apiKeyPattern.MatchString(key) {
+		return "", nil, fmt.Errorf("responseutils: invalid API key id %q", id)
+	}
+	return key, HashAPIKeySecret(secret), nil
+}
+
+// RedactAPIKey returns a key's ID with its secret masked, e.g.
+// "live_3f9a2c.****", for logs and error reports
+func RedactAPIKey(key string) string {
+	id, _, ok := strings.Cut(key, ".")
+	if !ok || id == "" {
+		return "****"
+	}
+	return id + ".****"
+}
+
+func MissingAPIKey(header string) *ResponseError {
+	return NewResponseError(
+		ErrCodeMissingAPIKey,
+		fmt.Sprintf("An API key is required in the %s header", header),
+		http.StatusUnauthorized,
+	)
+}
+
+func MalformedAPIKey() *ResponseError {
+	return NewResponseError(ErrCodeMalformedAPIKey, "The API key is malformed", http.StatusUnauthorized)
+}
+
+func InvalidAPIKey(id string) *ResponseError {
+	return NewResponseError(ErrCodeInvalidAPIKey, "The API key is invalid", http.StatusUnauthorized).
+		WithDetails("key_id", id)
+}
+
+func RevokedAPIKey(id string, revokedAt time.Time) *ResponseError {
+	return NewResponseError(ErrCodeRevokedAPIKey, "The API key has been revoked", http.StatusUnauthorized).
+		WithDetails("key_id", id).
+		WithDetails("revoked_at", revokedAt.UTC())
+}
+
+func ExpiredAPIKey(id string, expiredAt time.Time) *ResponseError {
+	return NewResponseError(ErrCodeExpiredAPIKey, "The API key has expired", http.StatusUnauthorized).
+		WithDetails("key_id", id).
+		WithDetails("expired_at", expiredAt.UTC())
+}
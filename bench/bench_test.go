@@ -0,0 +1,104 @@
+package bench
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+func BenchmarkOKResponse(b *testing.B) {
+	small := SmallObject()
+	list := LargeList(10000)
+	deep := DeepNesting(10)
+
+	b.Run("Small", writer(func(c *gin.Context) { responseutils.OKResponse(c, small, "ok") }))
+	b.Run("List10k", writer(func(c *gin.Context) { responseutils.OKResponse(c, list, "ok") }))
+	b.Run("DeepNesting", writer(func(c *gin.Context) { responseutils.OKResponse(c, deep, "ok") }))
+}
+
+func BenchmarkCreatedResponse(b *testing.B) {
+	small := SmallObject()
+	b.Run("Small", writer(func(c *gin.Context) { responseutils.CreatedResponse(c, small, "created") }))
+}
+
+func BenchmarkUpdatedResponse(b *testing.B) {
+	small := SmallObject()
+	b.Run("Small", writer(func(c *gin.Context) { responseutils.UpdatedResponse(c, small, "updated") }))
+}
+
+func BenchmarkNoContentResponse(b *testing.B) {
+	writer(responseutils.NoContentResponse)(b)
+}
+
+func BenchmarkErrorResponse(b *testing.B) {
+	b.Run("ResponseError", writer(func(c *gin.Context) { responseutils.ErrorResponse(c, DetailedError()) }))
+	b.Run("PlainError", writer(func(c *gin.Context) { responseutils.ErrorResponse(c, PlainError()) }))
+}
+
+func BenchmarkListResponseWithPagination(b *testing.B) {
+	list := LargeList(10000)
+	pagination := responseutils.CalculatePagination(1, len(list), len(list))
+	b.Run("List10k", writer(func(c *gin.Context) {
+		responseutils.ListResponseWithPagination(c, list, pagination)
+	}))
+}
+
+func BenchmarkDegradedResponse(b *testing.B) {
+	small := SmallObject()
+	b.Run("Small", writer(func(c *gin.Context) {
+		responseutils.DegradedResponse(c, small, []string{"recommendations"})
+	}))
+}
+
+func BenchmarkAggregateResponses(b *testing.B) {
+	aggregate := map[string]responseutils.Result{
+		"profile": {Data: SmallObject()},
+		"orders":  {Err: responseutils.InternalServerError("orders service unavailable")},
+	}
+	b.Run("BestEffort", writer(func(c *gin.Context) {
+		responseutils.AggregateResponses(c, aggregate, responseutils.BestEffort)
+	}))
+}
+
+// writer benchmarks a response writer against a fresh context per iteration
+func writer(write func(c *gin.Context)) func(b *testing.B) {
+	return func(b *testing.B) {
+		gin.SetMode(gin.ReleaseMode)
+		engine := gin.New()
+		req := httptest.NewRequest(http.MethodGet, "/bench", nil)
+		w := &discardWriter{header: make(http.Header)}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.reset()
+			c := gin.CreateTestContextOnly(w, engine)
+			c.Request = req
+			write(c)
+		}
+		b.SetBytes(int64(w.size))
+	}
+}
+
+// discardWriter is an http.ResponseWriter that records only the body size
+type discardWriter struct {
+	header http.Header
+	size   int
+}
+
+func (w *discardWriter) Header() http.Header { return w.header }
+
+func (w *discardWriter) WriteHeader(int) {}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	w.size += len(p)
+	return len(p), nil
+}
+
+func (w *discardWriter) reset() {
+	clear(w.header)
+	w.size = 0
+}
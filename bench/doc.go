@@ -0,0 +1,10 @@
+// Package bench provides payload fixtures and benchmarks for the response writers.
+//
+// The benchmarks run with go test, and their output can be compared with
+// benchstat:
+//
+//	go test -run '^$' -bench . -count 10 ./bench > old.txt
+//	# make changes
+//	go test -run '^$' -bench . -count 10 ./bench > new.txt
+//	benchstat old.txt new.txt
+package bench
@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// User is a realistic small API object
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Active    bool      `json:"active"`
+	Roles     []string  `json:"roles"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Node is a recursive payload used for deep nesting
+type Node struct {
+	Name     string  `json:"name"`
+	Value    int     `json:"value"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// SmallObject returns a single user object
+func SmallObject() User {
+	return newUser(1)
+}
+
+// LargeList returns a list of n user objects
+func LargeList(n int) []User {
+	users := make([]User, n)
+	for i := range users {
+		users[i] = newUser(i)
+	}
+	return users
+}
+
+// DeepNesting returns a tree of the given depth with two children per node
+func DeepNesting(depth int) *Node {
+	node := &Node{Name: fmt.Sprintf("node-%d", depth), Value: depth}
+	if depth > 0 {
+		node.Children = []*Node{DeepNesting(depth - 1), DeepNesting(depth - 1)}
+	}
+	return node
+}
+
+// DetailedError returns a validation error carrying field-level details
+func DetailedError() *responseutils.ResponseError {
+	return responseutils.ValidationError("User validation failed").SetFieldErrors(
+		responseutils.FieldError{Field: "email", Message: "Email is required"},
+		responseutils.FieldError{Field: "age", Message: "Must be at least 18 years old"},
+	).WithDetails("request_id", "req-123")
+}
+
+// PlainError returns an error that is not a *ResponseError
+func PlainError() error {
+	return errors.New("connection refused")
+}
+
+func newUser(i int) User {
+	return User{
+		ID:        fmt.Sprintf("usr_%08d", i),
+		Name:      fmt.Sprintf("User %d", i),
+		Email:     fmt.Sprintf("user%d@example.com", i),
+		Active:    i%2 == 0,
+		Roles:     []string{"reader", "writer"},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Minute),
+	}
+}
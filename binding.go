@@ -0,0 +1,282 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Field error codes used by the binders
+const (
+	FieldCodeInvalidType = "INVALID_TYPE"
+	FieldCodeInvalidTime = "INVALID_TIME"
+)
+
+// BindBody binds the request body with the binding matching its content type
+// and validates it. Binding errors, including validation failures, are
+// converted by BindingError.
+func BindBody[T any](c *gin.Context) (T, *ResponseError) {
+	var v T
+	b := binding.Default(c.Request.Method, c.ContentType())
+	tag := b.Name()
+	if strings.Contains(tag, "form") {
+		tag = "form"
+	}
+
+	var values map[string][]string
+	if tag == "form" {
+		if err := c.Request.ParseForm(); err == nil {
+			values = c.Request.PostForm
+		}
+	}
+	return v, bindingError(c.ShouldBindWith(&v, b), reflect.TypeOf(v), tag, values, false, ErrCodeInvalidBody)
+}
+
+// BindQuery binds and validates the query string
+func BindQuery[T any](c *gin.Context) (T, *ResponseError) {
+	var v T
+	return v, bindingError(c.ShouldBindQuery(&v), reflect.TypeOf(v), "form", c.Request.URL.Query(), false, ErrCodeInvalidInput)
+}
+
+// BindURI binds and validates the path parameters
+func BindURI[T any](c *gin.Context) (T, *ResponseError) {
+	var v T
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	return v, bindingError(c.ShouldBindUri(&v), reflect.TypeOf(v), "uri", params, false, ErrCodeInvalidInput)
+}
+
+// BindHeader binds and validates request headers
+func BindHeader[T any](c *gin.Context) (T, *ResponseError) {
+	var v T
+	return v, bindingError(c.ShouldBindHeader(&v), reflect.TypeOf(v), "header", c.Request.Header, true, ErrCodeInvalidInput)
+}
+
+// BindingError converts an error returned by gin's ShouldBind* methods into
+// a VALIDATION_ERROR with field-level details. Malformed bodies become
+// INVALID_BODY errors.
+func BindingError(err error) *ResponseError {
+	return bindingError(err, nil, "json", nil, false, ErrCodeInvalidBody)
+}
+
+// bindingError converts a binding error into a field-level validation error.
+// t is the bound type and tag the struct tag naming fields in the source, so
+// fields are reported by their request names. values are the source's raw
+// values, used to identify which field failed to parse.
+func bindingError(err error, t reflect.Type, tag string, values map[string][]string, foldKeys bool, malformedCode string) *ResponseError {
+	if err == nil {
+		return nil
+	}
+	if known := mapKnownError(err); known != nil {
+		return known
+	}
+	// errors from json.Unmarshaler implementations such as Money
+	var appErr *ResponseError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, FieldError{
+				Field:   requestFieldName(t, fe.StructNamespace(), tag, fe.Field()),
+				Message: validationMessage(fe),
+				Code:    strings.ToUpper(fe.Tag()),
+			})
+		}
+		return ValidationError("Request validation failed").SetFieldErrors(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return ValidationError("Request validation failed").SetFieldErrors(FieldError{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be of type %s", jsonTypeName(typeErr.Type)),
+			Code:    FieldCodeInvalidType,
+		})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return ValidationError("Request validation failed").SetFieldErrors(FieldError{
+			Field:   fieldWithValue(t, tag, values, foldKeys, numErr.Num),
+			Message: "must be a valid number",
+			Code:    FieldCodeInvalidType,
+		})
+	}
+
+	var timeErr *time.ParseError
+	if errors.As(err, &timeErr) {
+		return ValidationError("Request validation failed").SetFieldErrors(FieldError{
+			Field:   fieldWithValue(t, tag, values, foldKeys, timeErr.Value),
+			Message: fmt.Sprintf("must be a time in the format %s", timeErr.Layout),
+			Code:    FieldCodeInvalidTime,
+		})
+	}
+
+	if errors.Is(err, io.EOF) {
+		return NewResponseError(malformedCode, "Request body is empty", http.StatusBadRequest)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return NewResponseError(malformedCode, "Request body is not valid JSON", http.StatusBadRequest).
+			WithDetails("offset", syntaxErr.Offset)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return NewResponseError(malformedCode, "Request body is truncated", http.StatusBadRequest)
+	}
+
+	return NewResponseError(malformedCode, "Request could not be parsed", http.StatusBadRequest).
+		WithDetails("error", err.Error())
+}
+
+// validationMessage describes a failed validator tag
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4", "uuid_rfc4122", "uuid4_rfc4122":
+		return "must be a valid UUID"
+	case "url", "uri":
+		return "must be a valid URL"
+	case "datetime":
+		return "must be a time in the format " + fe.Param()
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// requestFieldName maps a validator struct namespace such as
+// "CreateUser.Address.City" to the request name "address.city" using tag
+func requestFieldName(t reflect.Type, namespace, tag, fallback string) string {
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fallback
+	}
+
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 0 && segments[0] == t.Name() {
+		segments = segments[1:]
+	}
+
+	names := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		name, index := seg, ""
+		if i := strings.IndexByte(seg, '['); i >= 0 {
+			name, index = seg[:i], seg[i:]
+		}
+
+		t = indirectType(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			names = append(names, seg)
+			continue
+		}
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			names = append(names, seg)
+			continue
+		}
+		names = append(names, tagName(sf, tag)+index)
+
+		t = sf.Type
+		if index != "" {
+			t = indirectType(t)
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+				t = t.Elem()
+			}
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// fieldWithValue returns the request name of the top-level field whose raw
+// source value is value, or "" when it cannot be identified
+func fieldWithValue(t reflect.Type, tag string, values map[string][]string, foldKeys bool, value string) string {
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := tagName(t.Field(i), tag)
+		raw := values[name]
+		if foldKeys {
+			raw = http.Header(values).Values(name)
+		}
+		for _, v := range raw {
+			if v == value {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// tagName returns the field's name in the given struct tag, defaulting to the Go name
+func tagName(sf reflect.StructField, tag string) string {
+	name := strings.Split(sf.Tag.Get(tag), ",")[0]
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonTypeName names a Go type the way API clients think of JSON values
+func jsonTypeName(t reflect.Type) string {
+	switch indirectType(t).Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
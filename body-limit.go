@@ -0,0 +1,37 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PayloadTooLarge creates a 413 error carrying the size limit in bytes
+func PayloadTooLarge(limit int64) *ResponseError {
+	return NewResponseError(
+		ErrCodePayloadTooLarge,
+		fmt.Sprintf("Request body exceeds the limit of %d bytes", limit),
+		http.StatusRequestEntityTooLarge,
+	).WithDetails("limit_bytes", limit)
+}
+
+// MaxBodySize returns middleware enforcing a maximum request body size.
+// Requests declaring a larger Content-Length are rejected immediately with
+// the standard 413 envelope. Other bodies, including multipart uploads, are
+// limited while being read; passing the resulting read or bind error to
+// ErrorResponse sends the same 413 envelope.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			ErrorResponse(c, PayloadTooLarge(limit))
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package responseutils
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BotChallenge describes a captcha or bot challenge the client must solve
+// before retrying the request
+// @Description Bot challenge structure
+type BotChallenge struct {
+	// Provider hints which widget to render, e.g. "recaptcha", "hcaptcha" or "turnstile"
+	Provider  string    `json:"provider" example:"turnstile"`
+	SiteKey   string    `json:"site_key,omitempty" example:"0x4AAAAAAAB"`
+	Token     string    `json:"token" example:"chl_3b9d1e"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// ChallengeRequired creates a CHALLENGE_REQUIRED error carrying the challenge
+// under details.challenge. statusCode is 403 or 429; anything else becomes 403.
+func ChallengeRequired(statusCode int, challenge BotChallenge) *ResponseError {
+	if statusCode != http.StatusTooManyRequests {
+		statusCode = http.StatusForbidden
+	}
+
+	return NewResponseError(
+		ErrCodeChallengeRequired,
+		"Please complete the challenge to continue",
+		statusCode,
+	).WithDetails(ChallengeKey, challenge)
+}
+
+// RiskScorer scores how likely a request comes from a bot, from 0 to 1
+type RiskScorer interface {
+	Score(c *gin.Context) (float64, error)
+}
+
+// RiskScorerFunc adapts a function to the RiskScorer interface
+type RiskScorerFunc func(c *gin.Context) (float64, error)
+
+// Score implements RiskScorer
+func (f RiskScorerFunc) Score(c *gin.Context) (float64, error) {
+	return f(c)
+}
+
+// BotChallengeOptions configure BotChallengeMiddleware
+type BotChallengeOptions struct {
+	Scorer RiskScorer
+	// Threshold is the score at or above which requests are challenged
+	Threshold float64
+	// StatusCode is 403 (default) or 429
+	StatusCode int
+	// Issue creates the challenge sent to the client
+	Issue func(c *gin.Context) (BotChallenge, error)
+	// Solved, when set, reports whether the request carries a solved
+	// challenge and can skip scoring
+	Solved func(c *gin.Context) bool
+}
+
+// BotChallengeMiddleware returns middleware scoring every request and
+// answering risky ones with the ChallengeRequired error. Scoring failures let
+// the request through, so an unavailable scorer never blocks traffic.
+func BotChallengeMiddleware(opts BotChallengeOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Solved != nil && opts.Solved(c) {
+			c.Next()
+			return
+		}
+
+		score, err := opts.Scorer.Score(c)
+		if err != nil {
+			debugPrintf("risk scorer failed on %s: %v", c.FullPath(), err)
+			c.Next()
+			return
+		}
+		if score < opts.Threshold {
+			c.Next()
+			return
+		}
+
+		challenge, err := opts.Issue(c)
+		if err != nil {
+			ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+		ErrorResponse(c, ChallengeRequired(opts.StatusCode, challenge))
+		c.Abort()
+	}
+}
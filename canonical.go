@@ -0,0 +1,201 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/gin-gonic/gin"
+)
+
+const canonicalKey = "responseutils.canonical"
+
+// CanonicalEncoder serializes with Base, then rewrites the output as canonical
+// JSON, see CanonicalJSON. A nil Base uses encoding/json.
+type CanonicalEncoder struct {
+	Base Encoder
+}
+
+// Marshal implements Encoder
+func (e CanonicalEncoder) Marshal(v interface{}) ([]byte, error) {
+	base := e.Base
+	if base == nil {
+		base = StdEncoder{}
+	}
+	data, err := base.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalJSON(data)
+}
+
+// CanonicalResponses returns middleware serializing the envelopes of a route
+// as canonical JSON with the current encoder, for signed or hashed responses
+// that clients must be able to reproduce byte for byte
+func CanonicalResponses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(canonicalKey, true)
+		c.Next()
+	}
+}
+
+// applyCanonical rewrites a serialized envelope as canonical JSON on
+// CanonicalResponses routes and when the current encoder is a
+// CanonicalEncoder, so the spliced meta and links members are ordered too
+func applyCanonical(c *gin.Context, data []byte) ([]byte, error) {
+	_, route := c.Get(canonicalKey)
+	_, global := CurrentEncoder().(CanonicalEncoder)
+	if !route && !global {
+		return data, nil
+	}
+	return CanonicalJSON(data)
+}
+
+// CanonicalJSON rewrites a JSON document deterministically, following the
+// RFC 8785 (JCS) rules: no insignificant whitespace, object members sorted by
+// their UTF-16 code units, minimal string escaping and ECMAScript number
+// formatting. Integers are kept exactly as written, so IDs beyond 2^53 are
+// never rounded.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("canonical json: trailing data after document")
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch node := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(node))
+	case string:
+		writeCanonicalString(buf, node)
+	case json.Number:
+		n, err := canonicalNumber(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(n)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range node {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, node[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("canonical json: unexpected %T", v)
+	}
+	return nil
+}
+
+// canonicalNumber formats a number as ECMAScript's Number.prototype.toString
+// does, keeping integer literals exact
+func canonicalNumber(n json.Number) (string, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if s == "-0" {
+			return "0", nil
+		}
+		return s, nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("canonical json: %s is not representable", s)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	// ECMAScript writes exponents without leading zeros: 1e-7, 1.5e+21
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	sign, digits := exp[:1], strings.TrimLeft(exp[1:], "0")
+	return mantissa + "e" + sign + digits, nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,88 @@
+package responseutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogMaxAge is how long clients may cache a catalog before revalidating
+// it; stale copies may be served for a week while revalidation happens
+const CatalogMaxAge = 24 * time.Hour
+
+const catalogStaleWhileRevalidate = 7 * 24 * time.Hour
+
+// CatalogItem is one entry of a reference-data catalog, such as a country
+// or a currency offered in a dropdown
+// @Description Catalog entry structure
+type CatalogItem struct {
+	ID         string `json:"id" example:"GB"`
+	Label      string `json:"label" example:"United Kingdom"`
+	Deprecated bool   `json:"deprecated" example:"false"`
+}
+
+// Catalog is the data of a catalog response
+// @Description Reference-data catalog structure
+type Catalog struct {
+	Name    string        `json:"name" example:"countries"`
+	Version string        `json:"version" example:"2024-06-01"`
+	Items   []CatalogItem `json:"items"`
+}
+
+const catalogKey = "responseutils.catalog"
+
+// CatalogETag returns the weak ETag of a catalog version. It is weak because
+// the bytes sent for one version still vary with meta, links and compression.
+func CatalogETag(name, version string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + version))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// CatalogResponse sends a reference-data catalog. The ETag is derived from
+// its name and version, so bump the version whenever the values change;
+// clients may cache it for CatalogMaxAge and a matching If-None-Match gets
+// 304 Not Modified. Shared caches may only store it while its envelope
+// carries no meta.
+func CatalogResponse(c *gin.Context, name string, values []CatalogItem, version string) {
+	etag := CatalogETag(name, version)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", catalogCacheControl("public"))
+	c.Set(catalogKey, true)
+
+	if values == nil {
+		values = []CatalogItem{}
+	}
+	statusCode := http.StatusOK
+	if c.Request != nil && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+		etagMatches(c.GetHeader("If-None-Match"), etag) {
+		// The envelope is still built so the 304 carries the Cache-Control
+		// the full response would; writeBody drops its body
+		statusCode = http.StatusNotModified
+	}
+	SuccessResponse(c, statusCode, Catalog{Name: name, Version: version, Items: values}, "")
+}
+
+// catalogCacheControl returns the Cache-Control of a catalog response
+func catalogCacheControl(scope string) string {
+	return scope + ", max-age=" + strconv.Itoa(int(CatalogMaxAge.Seconds())) +
+		", stale-while-revalidate=" + strconv.Itoa(int(catalogStaleWhileRevalidate.Seconds()))
+}
+
+// applyCatalogCacheScope keeps shared caches from storing catalog responses
+// whose envelope carries meta, which belongs to the request, and from
+// caching errors written in place of the catalog
+func applyCatalogCacheScope(c *gin.Context, e *Envelope) {
+	if _, ok := c.Get(catalogKey); !ok {
+		return
+	}
+	switch {
+	case e.StatusCode >= http.StatusBadRequest:
+		c.Header("Cache-Control", "no-store")
+	case len(e.Meta) > 0:
+		c.Header("Cache-Control", catalogCacheControl("private"))
+	}
+}
@@ -0,0 +1,117 @@
+package responseutils
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const channelKey = "responseutils.channel"
+
+// ChannelHeader is the conventional header naming the client channel
+const ChannelHeader = "X-Client-Channel"
+
+// Channel identifies the kind of client a response is written for
+type Channel string
+
+const (
+	ChannelMobile  Channel = "mobile"
+	ChannelWeb     Channel = "web"
+	ChannelPartner Channel = "partner"
+)
+
+// ChannelResolver resolves the channel of a request, returning "" when unknown
+type ChannelResolver func(c *gin.Context) Channel
+
+// HeaderChannelResolver resolves the channel from a request header, e.g. ChannelHeader
+func HeaderChannelResolver(header string) ChannelResolver {
+	return func(c *gin.Context) Channel {
+		return Channel(c.GetHeader(header))
+	}
+}
+
+// APIKeyChannelResolver resolves the channel from the API key sent in header
+func APIKeyChannelResolver(header string, keys map[string]Channel) ChannelResolver {
+	return func(c *gin.Context) Channel {
+		return keys[c.GetHeader(header)]
+	}
+}
+
+// ChannelMiddleware resolves the channel of every request with the first
+// resolver returning one
+func ChannelMiddleware(resolvers ...ChannelResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, resolve := range resolvers {
+			if ch := resolve(c); ch != "" {
+				c.Set(channelKey, ch)
+				break
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequestChannel returns the channel resolved for this request
+func RequestChannel(c *gin.Context) (Channel, bool) {
+	v, ok := c.Get(channelKey)
+	ch, _ := v.(Channel)
+	return ch, ok && ch != ""
+}
+
+var (
+	channelMessagesMu sync.RWMutex
+	channelMessages   = map[Channel]map[string]string{}
+)
+
+// SetChannelMessages sets the messages sent to a channel for error codes,
+// replacing the error's own message
+func SetChannelMessages(ch Channel, messages map[string]string) {
+	channelMessagesMu.Lock()
+	defer channelMessagesMu.Unlock()
+
+	m := make(map[string]string, len(messages))
+	for code, msg := range messages {
+		m[code] = msg
+	}
+	channelMessages[ch] = m
+}
+
+// ChannelMessage returns the message overriding code for a channel
+func ChannelMessage(ch Channel, code string) (string, bool) {
+	channelMessagesMu.RLock()
+	defer channelMessagesMu.RUnlock()
+
+	msg, ok := channelMessages[ch][code]
+	return msg, ok
+}
+
+// applyChannelMessage replaces the message of an error envelope with the
+// request channel's override for its code
+func applyChannelMessage(c *gin.Context, e *Envelope) {
+	ch, ok := RequestChannel(c)
+	if !ok {
+		return
+	}
+	msg, ok := ChannelMessage(ch, e.ErrorCode())
+	if !ok {
+		return
+	}
+
+	switch b := e.Body.(type) {
+	case ProblemDetails:
+		b.Detail = msg
+		e.Body = b
+	case Response:
+		body, ok := b.Error.(map[string]interface{})
+		if !ok {
+			return
+		}
+		overridden := make(map[string]interface{}, len(body))
+		for k, v := range body {
+			overridden[k] = v
+		}
+		overridden["message"] = msg
+		b.Error = overridden
+		e.Body = b
+	}
+}
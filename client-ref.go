@@ -0,0 +1,71 @@
+package responseutils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const clientRefKey = "responseutils.client_ref"
+
+const (
+	// ClientRefHeader carries a client-generated reference for the request
+	ClientRefHeader = "X-Client-Ref"
+	// ClientRefParam is the query parameter alternative to ClientRefHeader
+	ClientRefParam = "client_request_id"
+)
+
+// maxClientRefLength bounds the references echoed back to clients
+const maxClientRefLength = 128
+
+// WithClientRef echoes the request's client reference, read from the
+// X-Client-Ref header or the client_request_id query parameter, as
+// meta.client_ref and the X-Client-Ref header of the response written for the
+// request, success or error, so SPAs can correlate optimistic UI updates with
+// their outcome. It returns the reference, or "" when the request has none or
+// it is longer than 128 characters or not printable ASCII.
+func WithClientRef(c *gin.Context) string {
+	ref := c.GetHeader(ClientRefHeader)
+	if ref == "" {
+		ref = c.Query(ClientRefParam)
+	}
+	SetClientRef(c, ref)
+	return c.GetString(clientRefKey)
+}
+
+// SetClientRef sets the client reference echoed for the request, e.g. a
+// client_request_id field of the bound body, ignoring invalid references
+func SetClientRef(c *gin.Context, ref string) {
+	if !validClientRef(ref) {
+		return
+	}
+	c.Set(clientRefKey, ref)
+}
+
+// ClientRefMiddleware calls WithClientRef for every request
+func ClientRefMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		WithClientRef(c)
+		c.Next()
+	}
+}
+
+// applyClientRef echoes the request's client reference
+func applyClientRef(c *gin.Context, e *Envelope) {
+	ref := c.GetString(clientRefKey)
+	if ref == "" {
+		return
+	}
+	c.Header(ClientRefHeader, ref)
+	e.SetMeta("client_ref", ref)
+}
+
+func validClientRef(ref string) bool {
+	if ref == "" || len(ref) > maxClientRefLength {
+		return false
+	}
+	for i := 0; i < len(ref); i++ {
+		if ref[i] < 0x21 || ref[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,330 @@
+package responseutils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CloudEvents content types and spec version
+const (
+	CloudEventsContentType      = "application/cloudevents+json"
+	CloudEventsBatchContentType = "application/cloudevents-batch+json"
+	CloudEventsSpecVersion      = "1.0"
+)
+
+// cloudEventHeaderPrefix prefixes context attribute headers in binary content mode
+const cloudEventHeaderPrefix = "Ce-"
+
+// CloudEvent is a CloudEvents 1.0 event in its JSON format
+// @Description CloudEvents 1.0 event structure
+type CloudEvent struct {
+	SpecVersion     string                 `json:"specversion" example:"1.0"`
+	ID              string                 `json:"id" example:"9f1c2ab4e1d84c0b"`
+	Source          string                 `json:"source" example:"/users"`
+	Type            string                 `json:"type" example:"com.example.user.created"`
+	Subject         string                 `json:"subject,omitempty" example:"123"`
+	Time            *time.Time             `json:"time,omitempty"`
+	DataContentType string                 `json:"datacontenttype,omitempty" example:"application/json"`
+	DataSchema      string                 `json:"dataschema,omitempty"`
+	Data            interface{}            `json:"data,omitempty" swaggertype:"object"`
+	Extensions      map[string]interface{} `json:"-"`
+}
+
+// NewCloudEvent creates an event with a random ID and the current time
+func NewCloudEvent(source, eventType string, data interface{}) *CloudEvent {
+	now := time.Now().UTC()
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              newEventID(),
+		Source:          source,
+		Type:            eventType,
+		Time:            &now,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// WithSubject sets the subject of the event
+func (e *CloudEvent) WithSubject(subject string) *CloudEvent {
+	e.Subject = subject
+	return e
+}
+
+// WithExtension sets an extension context attribute
+func (e *CloudEvent) WithExtension(name string, value interface{}) *CloudEvent {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]interface{})
+	}
+	e.Extensions[name] = value
+	return e
+}
+
+// MarshalJSON implements json.Marshaler, flattening extensions into the top level
+func (e CloudEvent) MarshalJSON() ([]byte, error) {
+	type plain CloudEvent
+	body, err := json.Marshal(plain(e))
+	if err != nil || len(e.Extensions) == 0 {
+		return body, err
+	}
+
+	ext, err := json.Marshal(e.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(ext) <= 2 {
+		return body, nil
+	}
+	return append(append(body[:len(body)-1], ','), ext[1:]...), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, collecting unknown attributes as extensions
+func (e *CloudEvent) UnmarshalJSON(data []byte) error {
+	type plain CloudEvent
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return err
+	}
+	for name, raw := range attrs {
+		if isCloudEventAttribute(name) {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		e.WithExtension(name, value)
+	}
+	return nil
+}
+
+// Validate checks the event against the CloudEvents 1.0 required attributes,
+// returning an INVALID_CLOUD_EVENT error listing every invalid attribute
+func (e *CloudEvent) Validate() *ResponseError {
+	var fields []FieldError
+
+	if e.SpecVersion != CloudEventsSpecVersion {
+		fields = append(fields, FieldError{Field: "specversion", Message: "must be " + CloudEventsSpecVersion})
+	}
+	if e.ID == "" {
+		fields = append(fields, FieldError{Field: "id", Message: "is required"})
+	}
+	if e.Source == "" {
+		fields = append(fields, FieldError{Field: "source", Message: "is required"})
+	} else if _, err := url.Parse(e.Source); err != nil {
+		fields = append(fields, FieldError{Field: "source", Message: "must be a URI reference"})
+	}
+	if e.Type == "" {
+		fields = append(fields, FieldError{Field: "type", Message: "is required"})
+	}
+	if e.DataContentType != "" {
+		if _, _, err := mime.ParseMediaType(e.DataContentType); err != nil {
+			fields = append(fields, FieldError{Field: "datacontenttype", Message: "must be a media type"})
+		}
+	}
+	names := make([]string, 0, len(e.Extensions))
+	for name := range e.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !validExtensionName(name) {
+			fields = append(fields, FieldError{Field: name, Message: "extension names must be lowercase letters and digits"})
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return InvalidCloudEvent("Event does not conform to CloudEvents " + CloudEventsSpecVersion).SetFieldErrors(fields...)
+}
+
+// InvalidCloudEvent creates an INVALID_CLOUD_EVENT error
+func InvalidCloudEvent(message string) *ResponseError {
+	return NewResponseError(ErrCodeInvalidCloudEvent, message, http.StatusBadRequest)
+}
+
+// CloudEventResponse sends the event in structured content mode, the whole
+// event as an application/cloudevents+json body. Invalid events are reported
+// with the standard error envelope instead.
+func CloudEventResponse(c *gin.Context, statusCode int, event *CloudEvent) {
+	if err := event.Validate(); err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	writeCloudEvent(c, statusCode, CloudEventsContentType, event)
+}
+
+// CloudEventBinaryResponse sends the event in binary content mode: context
+// attributes as ce- headers and the data as the body
+func CloudEventBinaryResponse(c *gin.Context, statusCode int, event *CloudEvent) {
+	if err := event.Validate(); err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	for name, value := range event.attributes() {
+		c.Header(cloudEventHeaderPrefix+name, value)
+	}
+
+	contentType := event.DataContentType
+	if contentType == "" {
+		contentType = jsonContentType
+	}
+	if b, ok := event.Data.([]byte); ok {
+		writeBody(c, statusCode, contentType, b)
+		return
+	}
+	writeCloudEvent(c, statusCode, contentType, event.Data)
+}
+
+// writeCloudEvent serializes an event, or the data of a binary mode event,
+// with the current encoder. Unlike envelopes, events get no meta or links
+// members: CloudEvents extension attributes must be scalars, and binary mode
+// data need not be an object.
+func writeCloudEvent(c *gin.Context, statusCode int, contentType string, body interface{}) {
+	if !envelopeAcceptable(c, contentType) {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		ErrorResponse(c, NotAcceptable([]string{mediaType}))
+		return
+	}
+
+	data, err := CurrentEncoder().Marshal(body)
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	writeEncoded(c, &Envelope{StatusCode: statusCode, ContentType: contentType, Body: body}, data)
+}
+
+// ParseCloudEvent reads an incoming event in structured or binary content
+// mode and validates it. Failures are returned as INVALID_CLOUD_EVENT errors
+// ready for ErrorResponse. Binary mode data is kept as json.RawMessage for
+// JSON content types and []byte otherwise.
+func ParseCloudEvent(c *gin.Context) (*CloudEvent, *ResponseError) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if known := mapKnownError(err); known != nil {
+			return nil, known
+		}
+		return nil, InvalidCloudEvent("Event body could not be read").WithDetails("error", err.Error())
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType == CloudEventsContentType {
+		event := &CloudEvent{}
+		if err := json.Unmarshal(body, event); err != nil {
+			return nil, InvalidCloudEvent("Event is not valid JSON").WithDetails("error", err.Error())
+		}
+		return event, event.Validate()
+	}
+	if mediaType == CloudEventsBatchContentType {
+		return nil, InvalidCloudEvent("Batched events are not supported")
+	}
+
+	event := &CloudEvent{
+		SpecVersion:     c.GetHeader("Ce-Specversion"),
+		ID:              c.GetHeader("Ce-Id"),
+		Source:          c.GetHeader("Ce-Source"),
+		Type:            c.GetHeader("Ce-Type"),
+		Subject:         c.GetHeader("Ce-Subject"),
+		DataContentType: c.GetHeader("Content-Type"),
+		DataSchema:      c.GetHeader("Ce-Dataschema"),
+	}
+	if raw := c.GetHeader("Ce-Time"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, InvalidCloudEvent("Event time must be an RFC 3339 timestamp").WithDetails("time", raw)
+		}
+		event.Time = &t
+	}
+	for name, values := range c.Request.Header {
+		attr := strings.ToLower(strings.TrimPrefix(name, cloudEventHeaderPrefix))
+		if len(values) == 0 || !strings.HasPrefix(name, cloudEventHeaderPrefix) || isCloudEventAttribute(attr) {
+			continue
+		}
+		event.WithExtension(attr, values[0])
+	}
+	if len(body) > 0 {
+		if isJSONContentType(event.DataContentType) {
+			event.Data = json.RawMessage(body)
+		} else {
+			event.Data = body
+		}
+	}
+
+	return event, event.Validate()
+}
+
+// attributes returns the context attributes as header values for binary content mode
+func (e *CloudEvent) attributes() map[string]string {
+	attrs := map[string]string{
+		"Specversion": e.SpecVersion,
+		"Id":          e.ID,
+		"Source":      e.Source,
+		"Type":        e.Type,
+	}
+	if e.Subject != "" {
+		attrs["Subject"] = e.Subject
+	}
+	if e.Time != nil {
+		attrs["Time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.DataSchema != "" {
+		attrs["Dataschema"] = e.DataSchema
+	}
+	for name, value := range e.Extensions {
+		if s, ok := value.(string); ok {
+			attrs[name] = s
+			continue
+		}
+		if b, err := json.Marshal(value); err == nil {
+			attrs[name] = string(b)
+		}
+	}
+	return attrs
+}
+
+func isCloudEventAttribute(name string) bool {
+	switch name {
+	case "specversion", "id", "source", "type", "subject", "time", "datacontenttype", "dataschema", "data", "data_base64":
+		return true
+	}
+	return false
+}
+
+// validExtensionName reports whether name uses only lowercase letters and digits
+func validExtensionName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func cloudEventEngine(write func(c *gin.Context)) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(ResponseDefaultsMiddleware(func(*gin.Context) Defaults {
+		return Defaults{Locale: "en-GB", Tenant: "acme"}
+	}))
+	r.GET("/events", write)
+	return r
+}
+
+func TestCloudEventBinaryResponseNonObjectData(t *testing.T) {
+	r := cloudEventEngine(func(c *gin.Context) {
+		CloudEventBinaryResponse(c, http.StatusOK, NewCloudEvent("/numbers", "com.example.numbers", []int{1, 2, 3}))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	if got := w.Body.String(); got != "[1,2,3]" {
+		t.Errorf("body = %s, want [1,2,3]", got)
+	}
+	if w.Header().Get("ce-type") != "com.example.numbers" {
+		t.Errorf("ce-type = %q", w.Header().Get("ce-type"))
+	}
+}
+
+func TestCloudEventResponseHasNoEnvelopeMembers(t *testing.T) {
+	r := cloudEventEngine(func(c *gin.Context) {
+		CloudEventResponse(c, http.StatusOK, NewCloudEvent("/users", "com.example.user.created", map[string]string{"id": "1"}))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"meta", "links"} {
+		if _, ok := event[member]; ok {
+			t.Errorf("event has envelope member %q: %s", member, w.Body)
+		}
+	}
+	if event["type"] != "com.example.user.created" {
+		t.Errorf("type = %v", event["type"])
+	}
+}
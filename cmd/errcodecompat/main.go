@@ -0,0 +1,195 @@
+// Command errcodecompat diffs the error code registry between two versions
+// and exits 1 when codes were removed or changed status, so an upgrade
+// cannot silently break API consumers.
+//
+//	errcodecompat v1.4.0 v1.5.0
+//	errcodecompat v1.4.0 .
+//	errcodecompat errcodes.json ./service
+//
+// Each side is a snapshot written by responseutils.WriteErrorCodes, a local
+// module directory or a version of this module. Directories and versions
+// are resolved by building a small program against them, which needs the
+// go command and, for versions, access to the module proxy. A directory
+// only contributes the built-in codes of the response-utils version it
+// points at; to cover the codes a service registers itself, compare a
+// snapshot the service writes after registering them.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+const modulePath = "github.com/geekible-ltd/response-utils"
+
+// snapshotProgram prints the registry in the WriteErrorCodes format. It only
+// uses ErrorCodes so that it builds against versions older than the
+// snapshot functions.
+const snapshotProgram = `package main
+
+import (
+	"encoding/json"
+	"os"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+func main() {
+	if err := json.NewEncoder(os.Stdout).Encode(responseutils.ErrorCodes()); err != nil {
+		panic(err)
+	}
+}
+`
+
+func main() {
+	jsonOut := flag.Bool("json", false, "print the changes as JSON")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: errcodecompat [-json] old new")
+		os.Exit(2)
+	}
+
+	old, err := load(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	updated, err := load(flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+
+	changes := responseutils.CompareErrorCodes(old, updated)
+	breaking := false
+	for _, c := range changes {
+		breaking = breaking || c.Breaking()
+	}
+
+	if *jsonOut {
+		if changes == nil {
+			changes = []responseutils.ErrorCodeChange{}
+		}
+		data, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, c := range changes {
+			prefix := "  "
+			if c.Breaking() {
+				prefix = "! "
+			}
+			fmt.Println(prefix + c.String())
+		}
+		if breaking {
+			fmt.Println("breaking error code changes")
+		} else {
+			fmt.Printf("compatible, %d codes compared\n", len(updated))
+		}
+	}
+	if breaking {
+		os.Exit(1)
+	}
+}
+
+// load reads a snapshot file or builds one for a module directory or version
+func load(arg string) ([]responseutils.ErrorCodeInfo, error) {
+	info, err := os.Stat(arg)
+	switch {
+	case err == nil && !info.IsDir():
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		codes, err := responseutils.LoadErrorCodes(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		return codes, nil
+	case err == nil:
+		dir, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		return snapshot(arg, "-replace="+modulePath+"="+moduleRoot(dir))
+	case strings.HasPrefix(arg, "v"):
+		return snapshot(arg, "-require="+modulePath+"@"+arg)
+	default:
+		return nil, fmt.Errorf("%s is neither a snapshot, a directory nor a version", arg)
+	}
+}
+
+// moduleRoot finds the response-utils module a directory builds against:
+// the directory itself when it is the module, else its replacement or the
+// version required in its go.mod
+func moduleRoot(dir string) string {
+	cmd := exec.Command("go", "list", "-m", "-f", "{{if .Replace}}{{.Replace.Dir}}{{else}}{{.Dir}}{{end}}", modulePath)
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err == nil {
+		if root := strings.TrimSpace(string(out)); root != "" {
+			return root
+		}
+	}
+	return dir
+}
+
+// snapshot builds and runs the snapshot program in a scratch module whose
+// go.mod is edited with the given flag
+func snapshot(arg, edit string) ([]responseutils.ErrorCodeInfo, error) {
+	tmp, err := os.MkdirTemp("", "errcodecompat")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte(snapshotProgram), 0o644); err != nil {
+		return nil, err
+	}
+	steps := [][]string{
+		{"mod", "init", "errcodesnapshot"},
+		{"mod", "edit", edit},
+	}
+	if strings.HasPrefix(edit, "-replace=") {
+		steps = append(steps, []string{"mod", "edit", "-require=" + modulePath + "@v0.0.0"})
+	}
+	steps = append(steps, []string{"mod", "tidy"})
+	for _, step := range steps {
+		if err := run(tmp, nil, step...); err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := run(tmp, &out, "run", "."); err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return responseutils.LoadErrorCodes(&out)
+}
+
+func run(dir string, stdout *bytes.Buffer, args ...string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "errcodecompat:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,337 @@
+// Command respctl helps support and QA work with response envelopes.
+//
+//	respctl pretty [file...]
+//	respctl reference [-key-file f] ref1_...
+//	respctl cursor [-kind sync|search] [-key-file f] token
+//	respctl validate -contract contract.json -route "GET /orders/:id" [file...]
+//	respctl example [-problem] [-message m] NOT_FOUND
+//	respctl codes
+//
+// pretty indents captured envelopes, read from the files or stdin; JSON
+// lines of responseutils.RecordedExchange, e.g. dumped from a RingSink, are
+// printed as their request line, status and response body.
+//
+// reference decodes an encrypted error reference pasted from a ticket with
+// the key given to SetErrorReferenceKey, and cursor decodes an opaque cursor
+// or sync token, verifying sync tokens when their signing key is given. Keys
+// are read from -key-file, or else from the RESPCTL_KEY environment
+// variable.
+//
+// validate checks bodies against a route of a contract written by
+// ContractSchema.WriteJSON and exits 1 on violations. example prints the
+// response ErrorResponse, or with -problem ProblemResponse, sends for an
+// error code; only the built-in codes are known, so pass -status for codes a
+// service registers itself.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+const usage = `usage: respctl <command> [flags] [args]
+
+commands:
+  pretty     pretty-print captured envelopes or recorded exchanges
+  reference  decode an encrypted error reference
+  cursor     decode a cursor or sync token
+  validate   check bodies against a frozen envelope contract
+  example    print an example error response for an error code
+  codes      list the known error codes`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	args := os.Args[2:]
+	var err error
+	switch os.Args[1] {
+	case "pretty":
+		err = pretty(args)
+	case "reference":
+		err = reference(args)
+	case "cursor":
+		err = cursor(args)
+	case "validate":
+		err = validate(args)
+	case "example":
+		err = example(args)
+	case "codes":
+		err = codes()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func pretty(args []string) error {
+	fs := flag.NewFlagSet("pretty", flag.ExitOnError)
+	fs.Parse(args)
+
+	return eachInput(fs.Args(), func(name string, r io.Reader) error {
+		dec := json.NewDecoder(r)
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+
+			var x responseutils.RecordedExchange
+			if json.Unmarshal(raw, &x) == nil && x.StatusCode != 0 {
+				fmt.Printf("%s %s -> %d %s\n", x.Method, x.URL, x.StatusCode, http.StatusText(x.StatusCode))
+				raw = x.ResponseBody
+				if x.Truncated {
+					fmt.Println("(body truncated)")
+				}
+			}
+			printIndented(raw)
+		}
+	})
+}
+
+func reference(args []string) error {
+	fs := flag.NewFlagSet("reference", flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding the error reference key")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: respctl reference [-key-file f] reference")
+	}
+
+	key, err := readKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("no key: pass -key-file or set RESPCTL_KEY")
+	}
+	ref, err := responseutils.DecodeErrorReference(key, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printJSON(ref)
+}
+
+func cursor(args []string) error {
+	fs := flag.NewFlagSet("cursor", flag.ExitOnError)
+	kind := fs.String("kind", "", `cursor kind, "sync" or "search"; by default the payload is only decoded`)
+	keyFile := fs.String("key-file", "", "file holding the sync token signing key")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: respctl cursor [-kind sync|search] [-key-file f] token")
+	}
+	token := fs.Arg(0)
+
+	switch *kind {
+	case "sync":
+		key, err := readKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		responseutils.SetSyncTokenKey(key)
+		st, rerr := responseutils.DecodeSyncToken(token)
+		if rerr != nil {
+			return rerr
+		}
+		if key == nil {
+			fmt.Fprintln(os.Stderr, "respctl: signature not verified, no key given")
+		}
+		return printJSON(st)
+	case "search":
+		sc, rerr := responseutils.DecodeSearchCursor(token)
+		if rerr != nil {
+			return rerr
+		}
+		return printJSON(sc)
+	case "":
+		// signed tokens carry their signature after a "."
+		payload, _, _ := strings.Cut(token, ".")
+		raw, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			return errors.New("not a base64url cursor")
+		}
+		if !json.Valid(raw) {
+			return errors.New("cursor payload is not JSON")
+		}
+		printIndented(raw)
+		return nil
+	default:
+		return fmt.Errorf("unknown cursor kind %q", *kind)
+	}
+}
+
+func validate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	contractFile := fs.String("contract", "", "contract schema JSON file")
+	route := fs.String("route", "", `route to check against, e.g. "GET /orders/:id"`)
+	fs.Parse(args)
+	if *contractFile == "" || *route == "" {
+		return errors.New("usage: respctl validate -contract file -route route [file...]")
+	}
+
+	f, err := os.Open(*contractFile)
+	if err != nil {
+		return err
+	}
+	schema, err := responseutils.LoadContract(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", *contractFile, err)
+	}
+	if _, ok := schema[*route]; !ok {
+		return fmt.Errorf("route %q is not in the contract", *route)
+	}
+
+	failed := false
+	err = eachInput(fs.Args(), func(name string, r io.Reader) error {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		violations, err := schema.Violations(*route, body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, v := range violations {
+			fmt.Printf("%s: field %s is outside the contract\n", name, v)
+		}
+		failed = failed || len(violations) > 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func example(args []string) error {
+	fs := flag.NewFlagSet("example", flag.ExitOnError)
+	problem := fs.Bool("problem", false, "print RFC 7807 problem details instead of the envelope")
+	message := fs.String("message", "", "error message; defaults to the code's description")
+	status := fs.Int("status", 0, "status code, for codes that are not built in")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: respctl example [-problem] [-message m] [-status n] CODE")
+	}
+
+	code := fs.Arg(0)
+	info, ok := responseutils.LookupErrorCode(code)
+	if !ok {
+		if *status == 0 {
+			return fmt.Errorf("unknown error code %s, pass -status or see respctl codes", code)
+		}
+		info = responseutils.ErrorCodeInfo{Code: code, StatusCode: *status, Description: http.StatusText(*status)}
+	}
+	if *status != 0 {
+		info.StatusCode = *status
+	}
+	if *message == "" {
+		*message = info.Description
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/example", nil)
+	err := responseutils.NewResponseError(info.Code, *message, info.StatusCode)
+	if *problem {
+		responseutils.ProblemResponse(c, err)
+	} else {
+		responseutils.ErrorResponse(c, err)
+	}
+
+	fmt.Printf("HTTP %d %s\n", w.Code, http.StatusText(w.Code))
+	fmt.Printf("Content-Type: %s\n\n", w.Header().Get("Content-Type"))
+	printIndented(w.Body.Bytes())
+	return nil
+}
+
+func codes() error {
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for _, info := range responseutils.ErrorCodes() {
+		fmt.Fprintf(w, "%-32s %d  %s\n", info.Code, info.StatusCode, info.Description)
+	}
+	return nil
+}
+
+// eachInput calls fn for each named file, or for stdin when there are none
+func eachInput(names []string, fn func(name string, r io.Reader) error) error {
+	if len(names) == 0 {
+		return fn("stdin", os.Stdin)
+	}
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = fn(name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readKey(file string) ([]byte, error) {
+	if file == "" {
+		if key := os.Getenv("RESPCTL_KEY"); key != "" {
+			return []byte(key), nil
+		}
+		return nil, nil
+	}
+	key, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(key, "\r\n"), nil
+}
+
+func printIndented(raw []byte) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		// not JSON, e.g. an HTML error page
+		os.Stdout.Write(raw)
+		fmt.Println()
+		return
+	}
+	buf.WriteByte('\n')
+	buf.WriteTo(os.Stdout)
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "respctl:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,102 @@
+// Command respfuzz runs the fuzz targets with random mutations of their
+// built-in seeds, as a quick smoke check. Coverage-guided runs use the
+// native fuzz tests of package fuzz, e.g. go test -fuzz FuzzDetails ./fuzz.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/geekible-ltd/response-utils/fuzz"
+)
+
+func main() {
+	duration := flag.Duration("duration", 10*time.Second, "time spent on each target")
+	filter := flag.String("run", ".", "run only targets matching the regular expression")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed, for reproducing a run")
+	flag.Parse()
+
+	re, err := regexp.Compile(*filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "respfuzz: invalid -run expression: %v\n", err)
+		os.Exit(2)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	fmt.Printf("seed %d\n", *seed)
+	failed := false
+	for _, t := range fuzz.Targets() {
+		if !re.MatchString(t.Name) {
+			continue
+		}
+		n, input, err := run(t, rng, *duration)
+		if err != nil {
+			failed = true
+			fmt.Printf("FAIL %s after %d inputs: %v\ninput: %q\n", t.Name, n, err, input)
+			continue
+		}
+		fmt.Printf("ok   %s\t%d inputs\n", t.Name, n)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run feeds the target its seeds and then mutations of them until the
+// duration is up or an input fails
+func run(t fuzz.Target, rng *rand.Rand, duration time.Duration) (n int, input []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	for _, seed := range t.Seeds {
+		n++
+		input = seed
+		if err = t.F(input); err != nil {
+			return n, input, err
+		}
+	}
+	corpus := append([][]byte{{}}, t.Seeds...)
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		n++
+		input = mutate(rng, corpus)
+		if err = t.F(input); err != nil {
+			return n, input, err
+		}
+	}
+	return n, nil, nil
+}
+
+func mutate(rng *rand.Rand, corpus [][]byte) []byte {
+	b := append([]byte(nil), corpus[rng.Intn(len(corpus))]...)
+	for i := rng.Intn(4) + 1; i > 0; i-- {
+		switch op := rng.Intn(5); {
+		case op == 0 && len(b) > 0:
+			b[rng.Intn(len(b))] ^= byte(1 << rng.Intn(8))
+		case op == 1:
+			pos := rng.Intn(len(b) + 1)
+			b = append(b[:pos], append([]byte{byte(rng.Intn(256))}, b[pos:]...)...)
+		case op == 2 && len(b) > 0:
+			pos := rng.Intn(len(b))
+			b = append(b[:pos], b[pos+1:]...)
+		case op == 3:
+			// splice in part of another input
+			other := corpus[rng.Intn(len(corpus))]
+			if len(other) > 0 {
+				start := rng.Intn(len(other))
+				pos := rng.Intn(len(b) + 1)
+				b = append(b[:pos], append(append([]byte(nil), other[start:]...), b[pos:]...)...)
+			}
+		case op == 4 && len(b) > 0:
+			b = b[:rng.Intn(len(b))]
+		}
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+// Command respmock serves a mock of a service from example payloads, for
+// client integration tests in CI.
+//
+//	respmock -fixtures fixtures.json
+//	respmock -contract contract.json -recordings recordings.jsonl -addr :9090
+//
+// Fixtures are a JSON array of mockserver.Fixture. With -recordings each
+// file holds JSON lines of responseutils.RecordedExchange, e.g. dumped from a
+// RingSink, which are turned into fixtures for the routes of the -contract
+// schema. Routes of the contract without a fixture answer 501.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/geekible-ltd/response-utils/mockserver"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	fixturesFile := flag.String("fixtures", "", "JSON file of fixtures")
+	recordings := flag.String("recordings", "", "comma-separated files of recorded exchanges")
+	contractFile := flag.String("contract", "", "contract schema whose routes are served")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	flag.Parse()
+
+	opts := mockserver.Options{Latency: *latency}
+	if *contractFile != "" {
+		f, err := os.Open(*contractFile)
+		if err != nil {
+			fail(err)
+		}
+		schema, err := responseutils.LoadContract(f)
+		f.Close()
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *contractFile, err))
+		}
+		for route := range schema {
+			opts.Routes = append(opts.Routes, route)
+		}
+	}
+	if *fixturesFile != "" {
+		f, err := os.Open(*fixturesFile)
+		if err != nil {
+			fail(err)
+		}
+		fixtures, err := mockserver.LoadFixtures(f)
+		f.Close()
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", *fixturesFile, err))
+		}
+		opts.Fixtures = fixtures
+	}
+	if *recordings != "" {
+		var exchanges []*responseutils.RecordedExchange
+		for _, path := range strings.Split(*recordings, ",") {
+			if err := readRecordings(path, &exchanges); err != nil {
+				fail(err)
+			}
+		}
+		opts.Fixtures = append(opts.Fixtures, mockserver.FixturesFromRecordings(exchanges, opts.Routes)...)
+	}
+	if len(opts.Routes) == 0 && len(opts.Fixtures) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: respmock [-addr a] [-fixtures file] [-recordings files] [-contract file] [-latency d]")
+		os.Exit(2)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	log.Printf("respmock: serving %d fixtures on %s", len(opts.Fixtures), *addr)
+	if err := mockserver.New(opts).Run(*addr); err != nil {
+		fail(err)
+	}
+}
+
+func readRecordings(path string, exchanges *[]*responseutils.RecordedExchange) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for scanner.Scan() {
+		x := new(responseutils.RecordedExchange)
+		if err := json.Unmarshal(scanner.Bytes(), x); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		*exchanges = append(*exchanges, x)
+	}
+	return scanner.Err()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "respmock:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,15 @@
+// Command sharedmutationvet reports mutation of shared *ResponseError values.
+//
+// Usage:
+//
+//	go run github.com/geekible-ltd/response-utils/cmd/sharedmutationvet ./...
+package main
+
+import (
+	"github.com/geekible-ltd/response-utils/analysis/sharedmutation"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(sharedmutation.Analyzer)
+}
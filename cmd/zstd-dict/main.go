@@ -0,0 +1,95 @@
+// Command zstd-dict trains a zstd dictionary for ZstdResponses from sample
+// response bodies.
+//
+//	zstd-dict -o orders.dict samples/*.json
+//	zstd-dict -recordings -o orders.dict recordings.jsonl
+//
+// Arguments are sample files or directories of them. With -recordings each
+// file holds JSON lines of responseutils.RecordedExchange, e.g. dumped from a
+// RingSink, and their response bodies are used as samples. The dictionary's
+// hash, as sent by clients in Available-Dictionary, is printed on success.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+func main() {
+	out := flag.String("o", "response.dict", "output dictionary file")
+	size := flag.Int("size", responseutils.DefaultZstdDictionarySize, "maximum dictionary size in bytes")
+	recordings := flag.Bool("recordings", false, "read JSON lines of recorded exchanges instead of raw bodies")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: zstd-dict [-o file] [-size n] [-recordings] sample...")
+		os.Exit(2)
+	}
+
+	var samples [][]byte
+	for _, arg := range flag.Args() {
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if *recordings {
+				return readRecordings(path, &samples)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, data)
+			return nil
+		})
+		if err != nil {
+			fail(err)
+		}
+	}
+	if len(samples) == 0 {
+		fail(fmt.Errorf("no samples found"))
+	}
+
+	dict := responseutils.TrainZstdDictionary(samples, *size)
+	d, err := responseutils.NewZstdDictionary(dict)
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*out, dict, 0o644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("wrote %s: %d bytes from %d samples, hash %s\n", *out, len(dict), len(samples), d.Hash())
+}
+
+func readRecordings(path string, samples *[][]byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for scanner.Scan() {
+		var x responseutils.RecordedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &x); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(x.ResponseBody) > 0 && !x.Truncated {
+			*samples = append(*samples, x.ResponseBody)
+		}
+	}
+	return scanner.Err()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "zstd-dict:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,84 @@
+package responseutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const collectionETagKey = "responseutils.collection_etag"
+
+// CollectionETag computes a weak ETag for a collection from the latest
+// updated_at of its items and its total count. The request's query string is
+// included so every page and filter of a list gets its own tag.
+func CollectionETag(c *gin.Context, lastModified time.Time, count int) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(lastModified.UTC().UnixNano(), 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(count)))
+	h.Write([]byte{0})
+	if c.Request != nil {
+		h.Write([]byte(c.Request.URL.RawQuery))
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// SetCollectionVersion records the collection's version for
+// ListResponseWithPagination, which then sets the ETag header and answers a
+// matching If-None-Match with 304 Not Modified
+func SetCollectionVersion(c *gin.Context, lastModified time.Time, count int) {
+	c.Set(collectionETagKey, CollectionETag(c, lastModified, count))
+}
+
+// CollectionNotModified records the collection's version and, when the
+// client's copy is current, writes 304 Not Modified and returns true. Call it
+// before loading the page to skip the query entirely:
+//
+//	if responseutils.CollectionNotModified(c, maxUpdatedAt, count) {
+//		return
+//	}
+func CollectionNotModified(c *gin.Context, lastModified time.Time, count int) bool {
+	SetCollectionVersion(c, lastModified, count)
+	return writeNotModified(c)
+}
+
+// writeNotModified sets the recorded collection ETag and writes 304 when the
+// request's If-None-Match matches it
+func writeNotModified(c *gin.Context) bool {
+	etag := c.GetString(collectionETagKey)
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	if c.Request == nil || c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 requires for If-None-Match
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
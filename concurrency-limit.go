@@ -0,0 +1,120 @@
+package responseutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimitHeader reports a client's in-flight request limit
+const ConcurrencyLimitHeader = "X-Concurrency-Limit"
+
+// ConcurrencyKeyer identifies the client a request counts against; requests
+// for which it returns "" are not limited
+type ConcurrencyKeyer func(c *gin.Context) string
+
+// IPKeyer limits clients by IP address
+func IPKeyer() ConcurrencyKeyer {
+	return func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// HeaderKeyer limits clients by a request header such as X-Api-Key,
+// leaving requests without it unlimited. Keys hold a truncated SHA-256 of the
+// header value, so credentials are not kept in the limiter.
+func HeaderKeyer(header string) ConcurrencyKeyer {
+	return func(c *gin.Context) string {
+		if v := c.GetHeader(header); v != "" {
+			sum := sha256.Sum256([]byte(v))
+			return header + ":" + hex.EncodeToString(sum[:16])
+		}
+		return ""
+	}
+}
+
+func ConcurrencyLimitExceeded(limit int) *ResponseError {
+	return NewResponseError(ErrCodeConcurrencyLimit,
+		fmt.Sprintf("Too many concurrent requests; at most %d may be in flight", limit), http.StatusTooManyRequests).
+		WithDetails("limit", limit).
+		WithHeader(ConcurrencyLimitHeader, strconv.Itoa(limit)).
+		WithHeader("Retry-After", "1")
+}
+
+// ConcurrencyLimiter limits the requests each client may have in flight,
+// independently of rate limiting, so clients retrying in a stampede get a
+// distinct CONCURRENCY_LIMIT_EXCEEDED error
+type ConcurrencyLimiter struct {
+	limit    int
+	keyer    ConcurrencyKeyer
+	limitFor func(c *gin.Context, key string) int
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewConcurrencyLimiter creates a limiter allowing limit in-flight requests
+// per client as identified by keyer
+func NewConcurrencyLimiter(limit int, keyer ConcurrencyKeyer) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{limit: limit, keyer: keyer, inFlight: map[string]int{}}
+}
+
+// WithLimitFunc sets a per-client limit, e.g. from the client's plan; a
+// result of 0 or less uses the default limit
+func (l *ConcurrencyLimiter) WithLimitFunc(fn func(c *gin.Context, key string) int) *ConcurrencyLimiter {
+	l.limitFor = fn
+	return l
+}
+
+// InFlight returns the number of requests a client has in flight
+func (l *ConcurrencyLimiter) InFlight(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight[key]
+}
+
+// Middleware rejects requests from clients at their limit with a 429
+// CONCURRENCY_LIMIT_EXCEEDED error
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := l.keyer(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		limit := l.limit
+		if l.limitFor != nil {
+			if n := l.limitFor(c, key); n > 0 {
+				limit = n
+			}
+		}
+
+		l.mu.Lock()
+		if l.inFlight[key] >= limit {
+			l.mu.Unlock()
+			ErrorResponse(c, ConcurrencyLimitExceeded(limit))
+			c.Abort()
+			return
+		}
+		l.inFlight[key]++
+		l.mu.Unlock()
+
+		defer l.release(key)
+		c.Next()
+	}
+}
+
+func (l *ConcurrencyLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[key]--; l.inFlight[key] <= 0 {
+		delete(l.inFlight, key)
+	}
+}
@@ -0,0 +1,95 @@
+package responseutils
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	consistencyResolverKey = "responseutils.consistency_resolver"
+	consistencyTokenKey    = "responseutils.consistency_token"
+	issuedConsistencyKey   = "responseutils.issued_consistency_token"
+)
+
+// ConsistencyTokenHeader carries the consistency token of the client's
+// latest write on subsequent reads, and the issued token on write responses
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+// ConsistencyResolver implements read-your-writes on top of a replicated
+// database. Tokens are opaque to clients, e.g. an encoded WAL position or GTID.
+type ConsistencyResolver interface {
+	// Issue returns the token covering the writes made by a request, e.g.
+	// the primary's position after commit
+	Issue(c *gin.Context) (string, error)
+	// Await makes the reads of a request observe the writes covered by token,
+	// e.g. by waiting for a replica to catch up or routing to the primary.
+	// Tokens it cannot parse should be reported with InvalidConsistencyToken.
+	Await(c *gin.Context, token string) error
+}
+
+func InvalidConsistencyToken() *ResponseError {
+	return NewResponseError(ErrCodeInvalidConsistencyToken, "Consistency token is malformed", http.StatusBadRequest).
+		WithDetails("header", ConsistencyTokenHeader)
+}
+
+// ConsistencyMiddleware returns middleware passing the consistency token of
+// read requests to resolver.Await before the handler runs, and adding a token
+// from resolver.Issue to the meta and headers of successful write responses,
+// see SetConsistencyToken
+func ConsistencyMiddleware(resolver ConsistencyResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(consistencyResolverKey, resolver)
+
+		if token := c.GetHeader(ConsistencyTokenHeader); token != "" && isSafeMethod(c.Request.Method) {
+			if err := resolver.Await(c, token); err != nil {
+				ErrorResponse(c, err)
+				c.Abort()
+				return
+			}
+			c.Set(consistencyTokenKey, token)
+		}
+		c.Next()
+	}
+}
+
+// RequestConsistencyToken returns the consistency token a read request was
+// made with, after the resolver has awaited it
+func RequestConsistencyToken(c *gin.Context) (string, bool) {
+	token := c.GetString(consistencyTokenKey)
+	return token, token != ""
+}
+
+// SetConsistencyToken sets the token returned by a write response, instead
+// of asking the resolver, e.g. when the handler already knows its commit position
+func SetConsistencyToken(c *gin.Context, token string) {
+	c.Set(issuedConsistencyKey, token)
+}
+
+// applyConsistencyToken adds the consistency token to successful write
+// responses, as meta.consistency_token and the X-Consistency-Token header
+func applyConsistencyToken(c *gin.Context, e *Envelope) {
+	if c.Request == nil || isSafeMethod(c.Request.Method) || !e.Success() {
+		return
+	}
+
+	token := c.GetString(issuedConsistencyKey)
+	if v, ok := c.Get(consistencyResolverKey); ok && token == "" {
+		issued, err := v.(ConsistencyResolver).Issue(c)
+		if err != nil {
+			debugPrintf("consistency resolver failed on %s: %v", c.FullPath(), err)
+			return
+		}
+		token = issued
+	}
+	if token == "" {
+		return
+	}
+
+	c.Header(ConsistencyTokenHeader, token)
+	e.SetMeta("consistency_token", token)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,6 @@
+//go:build !responsecontract
+
+package responseutils
+
+// strictContract disables contract checks outside responsecontract builds
+const strictContract = false
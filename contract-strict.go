@@ -0,0 +1,6 @@
+//go:build responsecontract
+
+package responseutils
+
+// strictContract enables contract checks, see SetContract
+const strictContract = true
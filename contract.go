@@ -0,0 +1,166 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContractSchema is a frozen envelope contract: for each route, keyed by
+// method and path pattern ("GET /orders/:id"), the field paths its responses
+// may contain. Paths join object keys with "." and mark array elements with
+// "[]", e.g. "data.items[].sku"; a path ending in ".*" allows any fields
+// below it, e.g. "error.details.*".
+type ContractSchema map[string][]string
+
+var (
+	contractMu sync.RWMutex
+	contract   ContractSchema
+)
+
+// SetContract freezes the envelope contract. In builds with the
+// responsecontract tag, writing a field outside the contract of a frozen
+// route panics, so tests catch accidental contract changes before release;
+// otherwise the contract is not checked. Routes missing from the schema are
+// not checked either.
+//
+//	go test -tags responsecontract ./...
+func SetContract(schema ContractSchema) {
+	contractMu.Lock()
+	defer contractMu.Unlock()
+
+	contract = schema
+}
+
+// LoadContract reads a contract schema from JSON
+func LoadContract(r io.Reader) (ContractSchema, error) {
+	var schema ContractSchema
+	if err := json.NewDecoder(r).Decode(&schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Add adds the fields of a response body to a route's contract, for
+// generating the schema from recorded responses
+func (s ContractSchema) Add(route string, body []byte) error {
+	fields, err := ContractFields(body)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, f := range s[route] {
+		known[f] = true
+	}
+	for _, f := range fields {
+		if !known[f] {
+			known[f] = true
+			s[route] = append(s[route], f)
+		}
+	}
+	sort.Strings(s[route])
+	return nil
+}
+
+// WriteJSON writes the schema as indented JSON, for committing alongside tests
+func (s ContractSchema) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Violations returns the fields of body outside a route's contract
+func (s ContractSchema) Violations(route string, body []byte) ([]string, error) {
+	fields, err := ContractFields(body)
+	if err != nil {
+		return nil, err
+	}
+	allowed := s[route]
+
+	var violations []string
+	for _, f := range fields {
+		if !contractAllows(allowed, f) {
+			violations = append(violations, f)
+		}
+	}
+	return violations, nil
+}
+
+// ContractFields returns the field paths of a JSON document in the
+// ContractSchema notation
+func ContractFields(body []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	collectContractFields(v, "", found)
+	fields := make([]string, 0, len(found))
+	for f := range found {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+func collectContractFields(v interface{}, path string, found map[string]bool) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			found[p] = true
+			collectContractFields(child, p, found)
+		}
+	case []interface{}:
+		for _, child := range node {
+			collectContractFields(child, path+"[]", found)
+		}
+	}
+}
+
+func contractAllows(allowed []string, field string) bool {
+	for _, a := range allowed {
+		if a == field {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, ".*"); ok && strings.HasPrefix(field, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContract panics when a serialized envelope has fields outside the
+// frozen contract of its route. It only runs in responsecontract builds.
+func checkContract(c *gin.Context, data []byte) {
+	if !strictContract || c.Request == nil {
+		return
+	}
+	contractMu.RLock()
+	schema := contract
+	contractMu.RUnlock()
+
+	route := c.Request.Method + " " + c.FullPath()
+	if _, frozen := schema[route]; !frozen {
+		return
+	}
+	violations, err := schema.Violations(route, data)
+	if err != nil || len(violations) == 0 {
+		return
+	}
+	panic(fmt.Sprintf("responseutils: %s wrote fields outside its frozen contract: %s", route, strings.Join(violations, ", ")))
+}
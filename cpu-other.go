@@ -0,0 +1,11 @@
+//go:build !unix
+
+package responseutils
+
+import "time"
+
+// processCPUTime is not supported on this platform; CPU-based load shedding
+// needs LoadSheddingOptions.CPU
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
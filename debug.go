@@ -0,0 +1,44 @@
+package responseutils
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debug mode: 0 follows gin's mode, 1 is enabled, 2 is disabled
+var debugMode atomic.Int32
+
+// SetDebug enables or disables debug mode, overriding gin's mode
+func SetDebug(enabled bool) {
+	if enabled {
+		debugMode.Store(1)
+		return
+	}
+	debugMode.Store(2)
+}
+
+// DebugEnabled reports whether debug mode is on. Unless set with SetDebug,
+// it follows gin's debug mode.
+func DebugEnabled() bool {
+	switch debugMode.Load() {
+	case 1:
+		return true
+	case 2:
+		return false
+	}
+	return gin.IsDebugging()
+}
+
+// debugPrintf writes a debug message in gin's debug output format
+func debugPrintf(format string, values ...interface{}) {
+	if !DebugEnabled() {
+		return
+	}
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	fmt.Fprintf(gin.DefaultWriter, "[RESPONSE-UTILS-debug] "+format, values...)
+}
@@ -0,0 +1,76 @@
+package responseutils
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults are request-scoped values applied by every writer
+type Defaults struct {
+	// Locale is sent as Content-Language and in meta.locale
+	Locale string
+	// Tenant is sent in meta.tenant
+	Tenant string
+	// Version is sent in meta.version
+	Version string
+	// Message is used for success responses sent without a message
+	Message string
+}
+
+type defaultsKey struct{}
+
+// ContextWithResponseDefaults returns a copy of ctx carrying response defaults
+func ContextWithResponseDefaults(ctx context.Context, d Defaults) context.Context {
+	return context.WithValue(ctx, defaultsKey{}, d)
+}
+
+// ResponseDefaultsFromContext returns the response defaults carried by ctx
+func ResponseDefaultsFromContext(ctx context.Context) (Defaults, bool) {
+	d, ok := ctx.Value(defaultsKey{}).(Defaults)
+	return d, ok
+}
+
+// ResponseDefaultsMiddleware sets the response defaults for every request,
+// so helpers deeper in the stack don't need extra parameters
+func ResponseDefaultsMiddleware(resolve func(c *gin.Context) Defaults) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ContextWithResponseDefaults(c.Request.Context(), resolve(c)))
+		c.Next()
+	}
+}
+
+// applyDefaults applies the request's response defaults to the envelope,
+// taking the tenant from TenantMiddleware when the defaults set none
+func applyDefaults(c *gin.Context, e *Envelope) {
+	if c.Request == nil {
+		return
+	}
+	d, ok := ResponseDefaultsFromContext(c.Request.Context())
+	if d.Tenant == "" {
+		var tenant bool
+		d.Tenant, tenant = TenantFromContext(c.Request.Context())
+		ok = ok || tenant
+	}
+	if !ok {
+		return
+	}
+
+	if d.Locale != "" {
+		c.Header("Content-Language", d.Locale)
+		e.SetMeta("locale", d.Locale)
+	}
+	if d.Tenant != "" {
+		e.SetMeta("tenant", d.Tenant)
+	}
+	if d.Version != "" {
+		e.SetMeta("version", d.Version)
+	}
+
+	if d.Message != "" {
+		if r, ok := e.Body.(Response); ok && r.Success && r.Message == "" {
+			r.Message = d.Message
+			e.Body = r
+		}
+	}
+}
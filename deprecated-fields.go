@@ -0,0 +1,224 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecatedTag marks a struct field as deprecated, e.g.
+// `json:"full_name" deprecated:"use first_name and last_name"`
+const DeprecatedTag = "deprecated"
+
+// deprecatedWarning is sent when FeatureDeprecationWarning is enabled
+const deprecatedWarning = `299 - "Response contains deprecated fields: %s"`
+
+// deprecatedTypes caches whether a type can contain deprecated fields
+var deprecatedTypes sync.Map
+
+// DeprecatedFields returns the JSON paths of the deprecated fields present in
+// v's serialized form, sorted. Fields omitted by omitempty are not reported;
+// elements of slices and maps are reported as "items[].name" and "tags.*.name",
+// and elements of a top-level list by their own field names.
+func DeprecatedFields(v interface{}) []string {
+	found := map[string]bool{}
+	collectDeprecated(reflect.ValueOf(v), "", found, map[interface{}]bool{})
+
+	fields := make([]string, 0, len(found))
+	for f := range found {
+		fields = append(fields, strings.TrimPrefix(f, "[]."))
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// applyDeprecatedFields lists the deprecated fields in a success envelope's
+// data in meta.deprecated_fields, with a Warning header when enabled
+func applyDeprecatedFields(c *gin.Context, e *Envelope) {
+	if !e.Success() {
+		return
+	}
+	fields := DeprecatedFields(e.Data())
+	if len(fields) == 0 {
+		return
+	}
+
+	e.SetMeta("deprecated_fields", fields)
+	if FeatureEnabled(c, FeatureDeprecationWarning) {
+		c.Writer.Header().Add("Warning", strings.Replace(deprecatedWarning, "%s", strings.Join(fields, ", "), 1))
+	}
+}
+
+// collectDeprecated adds the deprecated fields of v to found. visiting holds
+// the pointers, maps and slices being walked, so cyclic data is walked once;
+// the encoder reports the cycle.
+func collectDeprecated(v reflect.Value, path string, found map[string]bool, visiting map[interface{}]bool) {
+	if !v.IsValid() {
+		return
+	}
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr {
+			if !enterDeprecated(v, visiting) {
+				return
+			}
+			defer leaveDeprecated(v, visiting)
+		}
+		v = v.Elem()
+	}
+
+	// Values wrapped by the writers are reported by their contents
+	if v.CanInterface() {
+		switch w := v.Interface().(type) {
+		case expandNode:
+			collectDeprecated(reflect.ValueOf(w.value), path, found, visiting)
+			for _, name := range w.names {
+				collectDeprecated(reflect.ValueOf(w.expanded[name]), joinPath(path, name), found, visiting)
+			}
+			return
+		case tombstone:
+			collectDeprecated(reflect.ValueOf(w.item), path, found, visiting)
+			return
+		case json.RawMessage, PreEncoded:
+			return
+		}
+	}
+
+	if !mayContainDeprecated(v.Type()) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			fv := v.Field(i)
+			if strings.Contains(opts, "omitempty") && fv.IsZero() {
+				continue
+			}
+
+			if sf.Anonymous && name == "" && indirectType(sf.Type).Kind() == reflect.Struct {
+				collectDeprecated(fv, path, found, visiting)
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			fieldPath := joinPath(path, name)
+			if _, ok := sf.Tag.Lookup(DeprecatedTag); ok {
+				found[fieldPath] = true
+			}
+			collectDeprecated(fv, fieldPath, found, visiting)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if !enterDeprecated(v, visiting) {
+				return
+			}
+			defer leaveDeprecated(v, visiting)
+		}
+		for i := 0; i < v.Len(); i++ {
+			collectDeprecated(v.Index(i), path+"[]", found, visiting)
+		}
+	case reflect.Map:
+		if !enterDeprecated(v, visiting) {
+			return
+		}
+		defer leaveDeprecated(v, visiting)
+		iter := v.MapRange()
+		for iter.Next() {
+			collectDeprecated(iter.Value(), joinPath(path, "*"), found, visiting)
+		}
+	}
+}
+
+// deprecatedVisit identifies a pointer, map or slice; slices also by length,
+// as subslices sharing an array are not cycles
+type deprecatedVisit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+func enterDeprecated(v reflect.Value, visiting map[interface{}]bool) bool {
+	key := deprecatedVisit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if visiting[key] {
+		return false
+	}
+	visiting[key] = true
+	return true
+}
+
+func leaveDeprecated(v reflect.Value, visiting map[interface{}]bool) {
+	key := deprecatedVisit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	delete(visiting, key)
+}
+
+// mayContainDeprecated reports whether values of t can hold deprecated
+// fields, so untagged types are skipped without walking their values
+func mayContainDeprecated(t reflect.Type) bool {
+	if cached, ok := deprecatedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	result := typeHasDeprecated(t, map[reflect.Type]bool{})
+	deprecatedTypes.Store(t, result)
+	return result
+}
+
+// typeHasDeprecated reports whether t can hold deprecated fields. Types being
+// visited count as untagged so recursive types terminate; their own fields
+// are checked where they were first entered.
+func typeHasDeprecated(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := deprecatedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return typeHasDeprecated(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if _, ok := sf.Tag.Lookup(DeprecatedTag); ok || typeHasDeprecated(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
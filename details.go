@@ -0,0 +1,76 @@
+package responseutils
+
+import "encoding/json"
+
+// FieldErrorsKey is the details key holding field-level validation errors
+const FieldErrorsKey = "fields"
+
+// Details holds additional error information. Like any map it serializes
+// with keys in sorted order, so clients receive deterministic payloads.
+type Details map[string]interface{}
+
+// FieldError describes a validation failure for a single field
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+	Code    string `json:"code,omitempty" example:"INVALID_FORMAT"`
+}
+
+// DetailString returns a string detail
+func (e *ResponseError) DetailString(key string) (string, bool) {
+	s, ok := e.Details[key].(string)
+	return s, ok
+}
+
+// DetailInt returns an integer detail, accepting any integer type, whole
+// floats and json.Number so decoded payloads can be read back
+func (e *ResponseError) DetailInt(key string) (int, bool) {
+	switch v := e.Details[key].(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// DetailBool returns a boolean detail
+func (e *ResponseError) DetailBool(key string) (bool, bool) {
+	b, ok := e.Details[key].(bool)
+	return b, ok
+}
+
+// SetFieldErrors sets the field-level validation errors of the error
+func (e *ResponseError) SetFieldErrors(errs ...FieldError) *ResponseError {
+	return e.WithDetails(FieldErrorsKey, errs)
+}
+
+// FieldErrors returns the field-level validation errors of the error
+func (e *ResponseError) FieldErrors() []FieldError {
+	errs, _ := e.Details[FieldErrorsKey].([]FieldError)
+	return errs
+}
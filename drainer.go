@@ -0,0 +1,137 @@
+package responseutils
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ShuttingDown(deadline time.Time) *ResponseError {
+	return NewResponseError(ErrCodeShuttingDown, "Server is shutting down, please retry", http.StatusServiceUnavailable).
+		WithDetails("drain_deadline", deadline.UTC().Format(time.RFC3339)).
+		WithHeader("Connection", "close")
+}
+
+// Drainer coordinates graceful shutdown: once draining starts new requests
+// get a SHUTTING_DOWN 503 with Connection: close, so clients reconnect to
+// another instance, while in-flight requests complete
+type Drainer struct {
+	draining atomic.Bool
+	inFlight sync.WaitGroup
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// NewDrainer creates a drainer
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// Middleware tracks in-flight requests and rejects new ones while draining
+func (d *Drainer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Requests are admitted under the lock Drain starts draining with, so
+		// none is added to inFlight once Wait may be waiting on it
+		d.mu.Lock()
+		if d.draining.Load() {
+			deadline := d.deadline
+			d.mu.Unlock()
+			ErrorResponse(c, ShuttingDown(deadline))
+			c.Abort()
+			return
+		}
+		d.inFlight.Add(1)
+		d.mu.Unlock()
+
+		defer d.inFlight.Done()
+		c.Next()
+	}
+}
+
+// Drain starts draining, giving in-flight requests until timeout from now to
+// complete. Calling it again keeps the first deadline.
+func (d *Drainer) Drain(timeout time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining.Load() {
+		return
+	}
+	d.deadline = time.Now().Add(timeout)
+	d.draining.Store(true)
+}
+
+// Draining reports whether draining has started
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Deadline returns the time by which in-flight requests must complete, or
+// the zero time before draining starts
+func (d *Drainer) Deadline() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.deadline
+}
+
+// Wait waits for in-flight requests to complete, or until the drain deadline
+// or ctx is done, whichever comes first. Call it after Drain, as requests
+// admitted while it waits may be missed.
+func (d *Drainer) Wait(ctx context.Context) error {
+	if deadline := d.Deadline(); !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown drains and shuts down srv: new requests are rejected, keep-alives
+// are disabled, in-flight requests get until timeout to complete and the
+// server is then shut down
+//
+//	sig := make(chan os.Signal, 1)
+//	signal.Notify(sig, syscall.SIGTERM)
+//	<-sig
+//	drainer.Shutdown(context.Background(), srv, 25*time.Second)
+func (d *Drainer) Shutdown(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	d.Drain(timeout)
+	srv.SetKeepAlivesEnabled(false)
+	waitErr := d.Wait(ctx)
+
+	ctx, cancel := context.WithDeadline(ctx, d.Deadline())
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	return waitErr
+}
+
+// ReadinessHandler answers 200 until draining starts and the SHUTTING_DOWN
+// 503 afterwards, so load balancers stop routing to the instance
+func (d *Drainer) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d.draining.Load() {
+			ErrorResponse(c, ShuttingDown(d.Deadline()))
+			return
+		}
+		OKResponse(c, nil, "Ready")
+	}
+}
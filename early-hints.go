@@ -0,0 +1,99 @@
+package responseutils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const preloadRelsKey = "responseutils.preload_rels"
+
+// EarlyHints sends a 103 Early Hints response preloading links, so browsers
+// can start fetching sub-resources while the handler is still working. The
+// Link headers are kept for the final response as well. It does nothing once
+// the response has started or when the writer is wrapped by middleware that
+// hides the underlying http.ResponseWriter.
+func EarlyHints(c *gin.Context, links []Link) {
+	if c.Writer.Written() {
+		return
+	}
+	w, ok := c.Writer.(interface{ Unwrap() http.ResponseWriter })
+	if !ok {
+		debugPrintf("early hints skipped on %s: response writer cannot be unwrapped", c.FullPath())
+		return
+	}
+
+	if addPreloadHeaders(c.Writer.Header(), links) {
+		w.Unwrap().WriteHeader(http.StatusEarlyHints)
+	}
+}
+
+// PreloadLinks returns middleware emitting a preload Link header for each
+// link of the envelope's links block with one of rels, e.g. the "customer"
+// and "items" links of an order
+func PreloadLinks(rels ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(preloadRelsKey, rels)
+		c.Next()
+	}
+}
+
+// applyPreloadLinks adds the preload headers of a PreloadLinks route
+func applyPreloadLinks(c *gin.Context, e *Envelope) {
+	v, ok := c.Get(preloadRelsKey)
+	if !ok {
+		return
+	}
+	rels := v.([]string)
+
+	var links []Link
+	for _, l := range e.Links {
+		if containsFold(rels, l.Rel) {
+			links = append(links, l)
+		}
+	}
+	addPreloadHeaders(c.Writer.Header(), links)
+}
+
+// addPreloadHeaders adds a preload Link header for each link fetched with GET
+// that is not already preloaded, reporting whether any was added
+func addPreloadHeaders(header http.Header, links []Link) bool {
+	added := false
+	for _, l := range links {
+		if l.Href == "" || (l.Method != "" && !strings.EqualFold(l.Method, http.MethodGet)) {
+			continue
+		}
+		as := preloadDestination(l.Type)
+		value := "<" + l.Href + ">; rel=preload; as=" + as
+		if as == "fetch" || as == "font" {
+			value += "; crossorigin=anonymous"
+		}
+		if l.Type != "" {
+			value += "; type=" + strconv.Quote(l.Type)
+		}
+		if containsFold(header.Values("Link"), value) {
+			continue
+		}
+		header.Add("Link", value)
+		added = true
+	}
+	return added
+}
+
+// preloadDestination returns the preload "as" value for a link's media type;
+// API resources and untyped links are preloaded as fetches
+func preloadDestination(mediaType string) string {
+	switch mt := strings.ToLower(mediaType); {
+	case mt == "text/css":
+		return "style"
+	case mt == "text/javascript", mt == "application/javascript":
+		return "script"
+	case strings.HasPrefix(mt, "image/"):
+		return "image"
+	case strings.HasPrefix(mt, "font/"):
+		return "font"
+	}
+	return "fetch"
+}
@@ -0,0 +1,112 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmptyListPolicy controls whether list endpoints answer 200 with an empty
+// list or 404 Not Found
+type EmptyListPolicy int32
+
+const (
+	// EmptyListOK sends an empty list as 200 OK and a missing parent
+	// resource, such as the user of /users/:id/orders, as 404 Not Found;
+	// this is the default
+	EmptyListOK EmptyListPolicy = iota
+	// EmptyListHideParent also sends a missing parent as 200 with an empty
+	// list, so clients cannot probe which parent IDs exist
+	EmptyListHideParent
+	// EmptyListNotFound sends every empty list as 404 Not Found, matching
+	// services built before the policy existed; prefer EmptyListOK
+	EmptyListNotFound
+)
+
+var emptyListPolicy atomic.Int32
+
+// SetEmptyListPolicy sets the policy applied by EmptyListResponse,
+// MissingParentResponse and ListResponseWithPagination
+func SetEmptyListPolicy(p EmptyListPolicy) {
+	emptyListPolicy.Store(int32(p))
+}
+
+// CurrentEmptyListPolicy returns the policy applied to empty lists
+func CurrentEmptyListPolicy() EmptyListPolicy {
+	return EmptyListPolicy(emptyListPolicy.Load())
+}
+
+// String returns the name of the policy
+func (p EmptyListPolicy) String() string {
+	switch p {
+	case EmptyListHideParent:
+		return "hide_parent"
+	case EmptyListNotFound:
+		return "not_found"
+	default:
+		return "ok"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p EmptyListPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// EmptyListResponse sends a list with no items: 200 OK with an empty data
+// array, or 404 Not Found under EmptyListNotFound
+func EmptyListResponse(c *gin.Context) {
+	if CurrentEmptyListPolicy() == EmptyListNotFound {
+		ErrorResponse(c, ErrNotFound)
+		return
+	}
+	writeJSON(c, http.StatusOK, ListResponse{Success: true, Data: []interface{}{}})
+}
+
+// MissingParentResponse answers a list request whose parent resource does
+// not exist: 404 Not Found naming the parent, or an empty list under
+// EmptyListHideParent
+func MissingParentResponse(c *gin.Context, parent string) {
+	if CurrentEmptyListPolicy() == EmptyListHideParent {
+		writeJSON(c, http.StatusOK, ListResponse{Success: true, Data: []interface{}{}})
+		return
+	}
+	ErrorResponse(c, ParentNotFound(parent))
+}
+
+func ParentNotFound(parent string) *ResponseError {
+	return NewResponseError(
+		ErrCodeNotFound,
+		fmt.Sprintf("%s not found", parent),
+		http.StatusNotFound,
+	).WithDetails("parent", parent)
+}
+
+// isEmptyList reports whether data is a nil value or an empty slice or array
+func isEmptyList(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// emptyListOf returns an empty slice of data's type, so nil slices are sent
+// as [] rather than null
+func emptyListOf(data interface{}) interface{} {
+	if data != nil {
+		if t := reflect.TypeOf(data); t.Kind() == reflect.Slice {
+			return reflect.MakeSlice(t, 0, 0).Interface()
+		}
+	}
+	return []interface{}{}
+}
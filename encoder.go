@@ -0,0 +1,52 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Encoder serializes response envelopes into JSON
+type Encoder interface {
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// EncoderFunc adapts a function to the Encoder interface
+type EncoderFunc func(v interface{}) ([]byte, error)
+
+// Marshal implements Encoder
+func (f EncoderFunc) Marshal(v interface{}) ([]byte, error) {
+	return f(v)
+}
+
+// StdEncoder encodes with encoding/json
+type StdEncoder struct{}
+
+// Marshal implements Encoder
+func (StdEncoder) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+var (
+	encoderMu     sync.RWMutex
+	activeEncoder Encoder = StdEncoder{}
+)
+
+// SetEncoder sets the encoder used by all response writers.
+// Passing nil restores the default encoding/json encoder.
+func SetEncoder(e Encoder) {
+	encoderMu.Lock()
+	defer encoderMu.Unlock()
+
+	if e == nil {
+		e = StdEncoder{}
+	}
+	activeEncoder = e
+}
+
+// CurrentEncoder returns the encoder used by all response writers
+func CurrentEncoder() Encoder {
+	encoderMu.RLock()
+	defer encoderMu.RUnlock()
+
+	return activeEncoder
+}
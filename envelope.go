@@ -0,0 +1,187 @@
+package responseutils
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope is a response about to be written. Body holds the response
+// structure (Response, ListResponse, DegradedResponseDTO, ...); Meta and
+// Links are serialized as top-level "meta" and "links" members when not empty.
+type Envelope struct {
+	StatusCode  int
+	ContentType string
+	Body        interface{}
+	Meta        map[string]interface{}
+	Links       []Link
+}
+
+// ResponseHook is invoked with every envelope before it is written
+type ResponseHook func(c *gin.Context, e *Envelope) error
+
+var (
+	hooksMu       sync.RWMutex
+	responseHooks []ResponseHook
+)
+
+// RegisterResponseHook registers a hook invoked before every JSON response is written.
+//
+// Hooks run in registration order and may mutate the envelope, e.g. to inject
+// meta, strip fields from Data or set headers. When a hook returns an error the
+// remaining hooks are skipped and the response is replaced by the error
+// response for that error, which is written without running hooks again.
+func RegisterResponseHook(hook ResponseHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	responseHooks = append(responseHooks, hook)
+}
+
+// ClearResponseHooks removes all registered response hooks
+func ClearResponseHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	responseHooks = nil
+}
+
+// SetMeta sets a meta value on the envelope
+func (e *Envelope) SetMeta(key string, value interface{}) {
+	if e.Meta == nil {
+		e.Meta = make(map[string]interface{})
+	}
+	e.Meta[key] = value
+}
+
+// AddLink adds a link to the envelope's links block
+func (e *Envelope) AddLink(rel, href string) {
+	e.Links = append(e.Links, Link{Rel: rel, Href: href})
+}
+
+// Success reports whether the envelope is a success response
+func (e *Envelope) Success() bool {
+	switch b := e.Body.(type) {
+	case ProblemDetails:
+		return false
+	case Response:
+		return b.Success
+	case ListResponse:
+		return b.Success
+	case DegradedResponseDTO:
+		return b.Success
+	case AggregateResponseDTO:
+		return b.Success
+	}
+	return e.StatusCode < 400
+}
+
+// ErrorCode returns the error code of an error envelope, or "" for success responses
+func (e *Envelope) ErrorCode() string {
+	switch b := e.Body.(type) {
+	case ProblemDetails:
+		return b.Code
+	case Response:
+		if body, ok := b.Error.(map[string]interface{}); ok {
+			code, _ := body["code"].(string)
+			return code
+		}
+	}
+	return ""
+}
+
+// Data returns the envelope's data
+func (e *Envelope) Data() interface{} {
+	switch b := e.Body.(type) {
+	case Response:
+		return b.Data
+	case ListResponse:
+		return b.Data
+	case DegradedResponseDTO:
+		return b.Data
+	case AggregateResponseDTO:
+		return b.Data
+	}
+	return nil
+}
+
+// SetData replaces the envelope's data. Aggregate responses keep their
+// per-source data, which must be modified through Body.
+func (e *Envelope) SetData(data interface{}) {
+	switch b := e.Body.(type) {
+	case Response:
+		b.Data = data
+		e.Body = b
+	case ListResponse:
+		b.Data = data
+		e.Body = b
+	case DegradedResponseDTO:
+		b.Data = data
+		e.Body = b
+	}
+}
+
+// runResponseHooks runs the registered hooks against the envelope
+func runResponseHooks(c *gin.Context, e *Envelope) error {
+	hooksMu.RLock()
+	hooks := responseHooks
+	hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(c, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errEnvelopeNotObject = errors.New("responseutils: envelope body must encode to a JSON object to carry meta or links")
+
+// encodeEnvelope serializes the envelope body and splices in the meta and links members
+func encodeEnvelope(enc Encoder, e *Envelope) ([]byte, error) {
+	data, err := enc.Marshal(e.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	type member struct {
+		key   string
+		value interface{}
+	}
+	var extras []member
+	if len(e.Meta) > 0 {
+		extras = append(extras, member{"meta", e.Meta})
+	}
+	if len(e.Links) > 0 {
+		extras = append(extras, member{"links", e.Links})
+	}
+	if len(extras) == 0 {
+		return data, nil
+	}
+
+	data = bytes.TrimRight(data, " \t\r\n")
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return nil, errEnvelopeNotObject
+	}
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	needComma := len(bytes.TrimSpace(data[1:len(data)-1])) > 0
+	for _, m := range extras {
+		value, err := enc.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		if needComma {
+			buf.WriteByte(',')
+		}
+		needComma = true
+		buf.WriteString(`"` + m.key + `":`)
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
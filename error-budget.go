@@ -0,0 +1,228 @@
+package responseutils
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorBudgetPath is the conventional route for the error budget handler
+const ErrorBudgetPath = "/internal/error-budget"
+
+const errorBudgetExhaustedKey = "responseutils.error_budget_exhausted"
+
+// RouteBudget represents the error budget status of a single route
+type RouteBudget struct {
+	Route        string  `json:"route"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	SuccessRatio float64 `json:"success_ratio"`
+	Objective    float64 `json:"objective"`
+	Remaining    float64 `json:"remaining"`
+	Exhausted    bool    `json:"exhausted"`
+}
+
+// ExhaustedHandler is invoked for requests to a route whose budget is exhausted.
+// Aborting the context skips the route handler.
+type ExhaustedHandler func(c *gin.Context, budget RouteBudget)
+
+// ErrorBudgetTracker tracks success/error ratios per route over a sliding window
+type ErrorBudgetTracker struct {
+	mu          sync.Mutex
+	objective   float64
+	window      time.Duration
+	buckets     int
+	minRequests int
+	routes      map[string]*budgetWindow
+	onExhausted ExhaustedHandler
+	now         func() time.Time
+}
+
+type budgetBucket struct {
+	start    time.Time
+	requests int
+	errors   int
+}
+
+type budgetWindow struct {
+	buckets []budgetBucket
+}
+
+// NewErrorBudgetTracker creates a tracker for the given success objective (e.g. 0.99)
+// measured over a sliding window
+func NewErrorBudgetTracker(objective float64, window time.Duration) *ErrorBudgetTracker {
+	return &ErrorBudgetTracker{
+		objective:   objective,
+		window:      window,
+		buckets:     10,
+		minRequests: 20,
+		routes:      make(map[string]*budgetWindow),
+		now:         time.Now,
+	}
+}
+
+// WithMinRequests sets the number of requests required before a budget can be exhausted
+func (t *ErrorBudgetTracker) WithMinRequests(n int) *ErrorBudgetTracker {
+	t.minRequests = n
+	return t
+}
+
+// OnExhausted registers a hook used to degrade requests once a route's budget is exhausted
+func (t *ErrorBudgetTracker) OnExhausted(fn ExhaustedHandler) *ErrorBudgetTracker {
+	t.onExhausted = fn
+	return t
+}
+
+// Record records the outcome of a single request against a route
+func (t *ErrorBudgetTracker) Record(route string, success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.routes[route]
+	if !ok {
+		w = &budgetWindow{buckets: make([]budgetBucket, t.buckets)}
+		t.routes[route] = w
+	}
+
+	bucket := t.bucketFor(w, t.now())
+	bucket.requests++
+	if !success {
+		bucket.errors++
+	}
+}
+
+// Status returns the current budget status of a route
+func (t *ErrorBudgetTracker) Status(route string) RouteBudget {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status(route, t.now())
+}
+
+// Snapshot returns the budget status of every tracked route, sorted by route
+func (t *ErrorBudgetTracker) Snapshot() []RouteBudget {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	budgets := make([]RouteBudget, 0, len(t.routes))
+	for route := range t.routes {
+		budgets = append(budgets, t.status(route, now))
+	}
+
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].Route < budgets[j].Route
+	})
+
+	return budgets
+}
+
+// Middleware records the outcome of every request and invokes the exhausted hook
+// for routes that have run out of budget. Responses with a 5xx status count as errors.
+func (t *ErrorBudgetTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			c.Next()
+			return
+		}
+
+		if budget := t.Status(route); budget.Exhausted {
+			c.Set(errorBudgetExhaustedKey, true)
+			if t.onExhausted != nil {
+				t.onExhausted(c, budget)
+				if c.IsAborted() {
+					return
+				}
+			}
+		}
+
+		c.Next()
+
+		t.Record(route, c.Writer.Status() < http.StatusInternalServerError)
+	}
+}
+
+// Handler sends the budget status of every tracked route
+func (t *ErrorBudgetTracker) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		OKResponse(c, t.Snapshot(), "Error budget status retrieved successfully")
+	}
+}
+
+// RegisterHandler registers the budget handler on ErrorBudgetPath
+func (t *ErrorBudgetTracker) RegisterHandler(r gin.IRoutes) {
+	r.GET(ErrorBudgetPath, t.Handler())
+}
+
+// ErrorBudgetExhausted reports whether the current route's budget was exhausted
+// when the request started
+func ErrorBudgetExhausted(c *gin.Context) bool {
+	return c.GetBool(errorBudgetExhaustedKey)
+}
+
+func (t *ErrorBudgetTracker) bucketSize() time.Duration {
+	size := t.window / time.Duration(t.buckets)
+	if size <= 0 {
+		size = time.Second
+	}
+	return size
+}
+
+func (t *ErrorBudgetTracker) bucketFor(w *budgetWindow, now time.Time) *budgetBucket {
+	size := t.bucketSize()
+	start := now.Truncate(size)
+	idx := int(start.UnixNano()/int64(size)) % len(w.buckets)
+
+	bucket := &w.buckets[idx]
+	if !bucket.start.Equal(start) {
+		*bucket = budgetBucket{start: start}
+	}
+	return bucket
+}
+
+func (t *ErrorBudgetTracker) status(route string, now time.Time) RouteBudget {
+	budget := RouteBudget{
+		Route:        route,
+		Objective:    t.objective,
+		SuccessRatio: 1,
+		Remaining:    1,
+	}
+
+	w, ok := t.routes[route]
+	if !ok {
+		return budget
+	}
+
+	cutoff := now.Add(-t.window)
+	for _, bucket := range w.buckets {
+		if bucket.start.After(cutoff) {
+			budget.Requests += bucket.requests
+			budget.Errors += bucket.errors
+		}
+	}
+
+	if budget.Requests == 0 {
+		return budget
+	}
+
+	errorRatio := float64(budget.Errors) / float64(budget.Requests)
+	budget.SuccessRatio = 1 - errorRatio
+
+	allowed := 1 - t.objective
+	if allowed > 0 {
+		budget.Remaining = 1 - errorRatio/allowed
+	} else if budget.Errors > 0 {
+		budget.Remaining = 0
+	}
+	if budget.Remaining < 0 {
+		budget.Remaining = 0
+	}
+
+	budget.Exhausted = budget.Requests >= t.minRequests && budget.Remaining == 0
+
+	return budget
+}
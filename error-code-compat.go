@@ -0,0 +1,92 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Kinds of ErrorCodeChange
+const (
+	ErrorCodeAdded         = "added"
+	ErrorCodeRemoved       = "removed"
+	ErrorCodeStatusChanged = "status_changed"
+)
+
+// ErrorCodeChange is a difference between two error code registries
+type ErrorCodeChange struct {
+	Code      string `json:"code"`
+	Kind      string `json:"kind"`
+	OldStatus int    `json:"old_status,omitempty"`
+	NewStatus int    `json:"new_status,omitempty"`
+}
+
+// Breaking reports whether the change can break API consumers: a removed
+// code or one now sent with another status
+func (c ErrorCodeChange) Breaking() bool {
+	return c.Kind != ErrorCodeAdded
+}
+
+// String formats the change for reports
+func (c ErrorCodeChange) String() string {
+	switch c.Kind {
+	case ErrorCodeAdded:
+		return fmt.Sprintf("added %s (%d)", c.Code, c.NewStatus)
+	case ErrorCodeRemoved:
+		return fmt.Sprintf("removed %s (%d)", c.Code, c.OldStatus)
+	default:
+		return fmt.Sprintf("%s changed status from %d to %d", c.Code, c.OldStatus, c.NewStatus)
+	}
+}
+
+// CompareErrorCodes returns the changes from the old registry to the
+// updated one, sorted by code. Description changes are not reported.
+func CompareErrorCodes(old, updated []ErrorCodeInfo) []ErrorCodeChange {
+	before := make(map[string]ErrorCodeInfo, len(old))
+	for _, info := range old {
+		before[info.Code] = info
+	}
+	after := make(map[string]ErrorCodeInfo, len(updated))
+	for _, info := range updated {
+		after[info.Code] = info
+	}
+
+	var changes []ErrorCodeChange
+	for code, o := range before {
+		n, ok := after[code]
+		switch {
+		case !ok:
+			changes = append(changes, ErrorCodeChange{Code: code, Kind: ErrorCodeRemoved, OldStatus: o.StatusCode})
+		case n.StatusCode != o.StatusCode:
+			changes = append(changes, ErrorCodeChange{Code: code, Kind: ErrorCodeStatusChanged, OldStatus: o.StatusCode, NewStatus: n.StatusCode})
+		}
+	}
+	for code, n := range after {
+		if _, ok := before[code]; !ok {
+			changes = append(changes, ErrorCodeChange{Code: code, Kind: ErrorCodeAdded, NewStatus: n.StatusCode})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Code < changes[j].Code })
+	return changes
+}
+
+// WriteErrorCodes writes the registered error codes as indented JSON, for
+// committing a snapshot that later versions are compared against
+func WriteErrorCodes(w io.Writer) error {
+	data, err := json.MarshalIndent(ErrorCodes(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// LoadErrorCodes reads a snapshot written by WriteErrorCodes
+func LoadErrorCodes(r io.Reader) ([]ErrorCodeInfo, error) {
+	var codes []ErrorCodeInfo
+	if err := json.NewDecoder(r).Decode(&codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
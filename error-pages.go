@@ -0,0 +1,104 @@
+package responseutils
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const htmlContentType = "text/html; charset=utf-8"
+
+// ErrorPageData is passed to the HTML error page template
+type ErrorPageData struct {
+	StatusCode int
+	StatusText string
+	Code       string
+	Message    string
+	Details    Details
+	Path       string
+}
+
+// DefaultErrorPageTemplate is the built-in HTML error page
+var DefaultErrorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.StatusCode}} {{.StatusText}}</title>
+<style>body{font-family:system-ui,sans-serif;max-width:40rem;margin:4rem auto;padding:0 1rem;color:#222}code{color:#666}</style>
+</head>
+<body>
+<h1>{{.StatusCode}} {{.StatusText}}</h1>
+<p>{{.Message}}</p>
+<p><code>{{.Code}}</code></p>
+</body>
+</html>
+`))
+
+var (
+	errorPageMu       sync.RWMutex
+	errorPageTemplate *template.Template
+)
+
+// EnableHTMLErrorPages makes ErrorResponse render an HTML error page when the
+// client prefers text/html over JSON, e.g. browsers hitting OAuth callbacks
+// or download links directly. A nil template uses DefaultErrorPageTemplate.
+func EnableHTMLErrorPages(tmpl *template.Template) {
+	errorPageMu.Lock()
+	defer errorPageMu.Unlock()
+
+	if tmpl == nil {
+		tmpl = DefaultErrorPageTemplate
+	}
+	errorPageTemplate = tmpl
+}
+
+// DisableHTMLErrorPages makes ErrorResponse always send JSON
+func DisableHTMLErrorPages() {
+	errorPageMu.Lock()
+	defer errorPageMu.Unlock()
+
+	errorPageTemplate = nil
+}
+
+// prefersHTML reports whether the request prefers HTML over JSON
+func prefersHTML(c *gin.Context) bool {
+	if c.Request == nil {
+		return false
+	}
+	mediaType, ok := NegotiateMediaType(c.GetHeader("Accept"), "application/json", "text/html")
+	return ok && mediaType == "text/html"
+}
+
+// writeErrorPage renders the HTML error page for err, reporting false when
+// HTML error pages are disabled or the client prefers JSON
+func writeErrorPage(c *gin.Context, err error) bool {
+	errorPageMu.RLock()
+	tmpl := errorPageTemplate
+	errorPageMu.RUnlock()
+
+	if tmpl == nil || !prefersHTML(c) {
+		return false
+	}
+
+	statusCode, body := errorBody(err)
+	data := ErrorPageData{
+		StatusCode: statusCode,
+		StatusText: http.StatusText(statusCode),
+		Path:       c.Request.URL.Path,
+	}
+	data.Code, _ = body["code"].(string)
+	data.Message, _ = body["message"].(string)
+	data.Details, _ = body["details"].(Details)
+
+	var buf bytes.Buffer
+	if execErr := tmpl.Execute(&buf, data); execErr != nil {
+		_ = c.Error(execErr)
+		return false
+	}
+
+	writeBody(c, statusCode, htmlContentType, buf.Bytes())
+	return true
+}
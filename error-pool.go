@@ -0,0 +1,86 @@
+package responseutils
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPooledDetails is the largest details map kept for reuse; bigger maps
+// are left to the garbage collector so the pool does not pin their memory
+const maxPooledDetails = 64
+
+var responseErrorPool = sync.Pool{
+	New: func() interface{} { return new(ResponseError) },
+}
+
+var detailsPool = sync.Pool{
+	New: func() interface{} { return make(Details) },
+}
+
+// AcquireResponseError returns a ResponseError from the pool, for endpoints
+// answering with errors often enough that their allocations show up.
+//
+// It is released back to the pool by ErrorResponse and ProblemResponse once
+// the response is written, so it must not be used, stored or written again
+// afterwards. The written envelope keeps its code and message but drops its
+// details. Errors that outlive the response should use NewResponseError, or
+// be frozen or cloned, which takes them out of the pool.
+//
+// Only the error and its details are pooled. The Envelope and Response built
+// around it are not, since WrittenEnvelope and the middlewares read them after
+// the handler returns and nothing marks when the last of them is done.
+func AcquireResponseError(code string, message string, statusCode int) *ResponseError {
+	e := responseErrorPool.Get().(*ResponseError)
+	e.Code = code
+	e.Message = message
+	e.StatusCode = statusCode
+	e.Details = detailsPool.Get().(Details)
+	e.pooled = true
+	return e
+}
+
+// Release returns an error obtained from AcquireResponseError to the pool.
+// The writers call it for you; it only needs calling directly for pooled
+// errors that end up not being written. Other errors are left untouched.
+func (e *ResponseError) Release() {
+	if e == nil || !e.pooled || e.frozen {
+		return
+	}
+	if e.Details != nil && len(e.Details) <= maxPooledDetails {
+		clear(e.Details)
+		detailsPool.Put(e.Details)
+	}
+	*e = ResponseError{}
+	responseErrorPool.Put(e)
+}
+
+// Pooled reports whether the error was obtained from AcquireResponseError
+// and has not been released yet
+func (e *ResponseError) Pooled() bool {
+	return e.pooled
+}
+
+// releaseWrittenError releases a pooled error once its response is written,
+// first detaching its details from the envelope kept for WrittenEnvelope so
+// later readers never see a map already reused by another request
+func releaseWrittenError(c *gin.Context, err error) {
+	var appErr *ResponseError
+	if !errors.As(err, &appErr) || !appErr.pooled {
+		return
+	}
+
+	if e, ok := WrittenEnvelope(c); ok {
+		switch b := e.Body.(type) {
+		case Response:
+			if body, ok := b.Error.(map[string]interface{}); ok {
+				delete(body, "details")
+			}
+		case ProblemDetails:
+			b.Details = nil
+			e.Body = b
+		}
+	}
+	appErr.Release()
+}
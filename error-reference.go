@@ -0,0 +1,221 @@
+package responseutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header request IDs are read from, on the request or
+// as already set on the response by a request ID middleware
+const RequestIDHeader = "X-Request-ID"
+
+// ErrorReferenceHeader carries the error reference of a 5xx response
+const ErrorReferenceHeader = "X-Error-Reference"
+
+const errorReferencePrefix = "ref1_"
+
+// maxReferenceDetail caps the internal error message kept in a reference
+const maxReferenceDetail = 512
+
+// ErrorReference is what an encrypted error reference carries. It is only
+// readable with the reference key, so internals stay out of the payload.
+type ErrorReference struct {
+	RequestID   string    `json:"rid,omitempty"`
+	Fingerprint string    `json:"fp"`
+	Timestamp   time.Time `json:"ts"`
+	Status      int       `json:"st"`
+	Code        string    `json:"c"`
+	Route       string    `json:"r,omitempty"`
+	// Detail is the internal error message removed from details.error
+	Detail string `json:"d,omitempty"`
+}
+
+var (
+	errorReferenceMu  sync.RWMutex
+	errorReferenceKey []byte
+)
+
+// SetErrorReferenceKey enables encrypted error references on 5xx responses.
+// The error body gets a "reference" field and the X-Error-Reference header
+// which support can decode with DecodeErrorReference, and the internal
+// message in details.error is moved into the reference instead of being
+// sent. A nil key disables references.
+func SetErrorReferenceKey(secret []byte) {
+	errorReferenceMu.Lock()
+	defer errorReferenceMu.Unlock()
+	errorReferenceKey = nil
+	if len(secret) > 0 {
+		errorReferenceKey = referenceKey(secret)
+	}
+}
+
+// EncodeErrorReference encrypts a reference with AES-256-GCM under a key
+// derived from secret
+func EncodeErrorReference(secret []byte, ref ErrorReference) (string, error) {
+	return encodeErrorReference(referenceKey(secret), ref)
+}
+
+// DecodeErrorReference decrypts a reference a user pasted into a support
+// ticket, with the secret given to SetErrorReferenceKey
+func DecodeErrorReference(secret []byte, reference string) (ErrorReference, error) {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(reference), errorReferencePrefix)
+	if !ok {
+		return ErrorReference{}, errors.New("responseutils: not an error reference")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return ErrorReference{}, errors.New("responseutils: malformed error reference")
+	}
+
+	gcm, err := referenceCipher(referenceKey(secret))
+	if err != nil {
+		return ErrorReference{}, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return ErrorReference{}, errors.New("responseutils: malformed error reference")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return ErrorReference{}, errors.New("responseutils: error reference was not issued with this key")
+	}
+
+	var ref ErrorReference
+	if err := json.Unmarshal(plaintext, &ref); err != nil {
+		return ErrorReference{}, errors.New("responseutils: malformed error reference")
+	}
+	return ref, nil
+}
+
+// ErrorFingerprint groups occurrences of the same failure: the same code
+// on the same route with the same internal message
+func ErrorFingerprint(route, code, detail string) string {
+	sum := sha256.Sum256([]byte(route + "\x00" + code + "\x00" + detail))
+	return hex.EncodeToString(sum[:8])
+}
+
+// applyErrorReference adds an encrypted reference to 5xx error envelopes
+// when SetErrorReferenceKey has enabled them
+func applyErrorReference(c *gin.Context, e *Envelope) {
+	if e.StatusCode < http.StatusInternalServerError {
+		return
+	}
+	errorReferenceMu.RLock()
+	key := errorReferenceKey
+	errorReferenceMu.RUnlock()
+	if key == nil {
+		return
+	}
+
+	var code string
+	var details Details
+	switch b := e.Body.(type) {
+	case Response:
+		body, ok := b.Error.(map[string]interface{})
+		if !ok {
+			return
+		}
+		code, _ = body["code"].(string)
+		details, _ = body["details"].(Details)
+	case ProblemDetails:
+		code, details = b.Code, b.Details
+	default:
+		return
+	}
+
+	detail, _ := details["error"].(string)
+	if len(detail) > maxReferenceDetail {
+		detail = detail[:maxReferenceDetail]
+	}
+	route := ""
+	if c.Request != nil {
+		route = c.Request.Method + " " + c.FullPath()
+	}
+	ref := ErrorReference{
+		RequestID:   requestID(c),
+		Fingerprint: ErrorFingerprint(route, code, detail),
+		Timestamp:   time.Now().UTC().Truncate(time.Millisecond),
+		Status:      e.StatusCode,
+		Code:        code,
+		Route:       route,
+		Detail:      detail,
+	}
+	reference, err := encodeErrorReference(key, ref)
+	if err != nil {
+		return
+	}
+
+	// copy the details without the internal message rather than mutating
+	// them, as they may belong to a shared or pooled error
+	var public Details
+	for k, v := range details {
+		if k == "error" {
+			continue
+		}
+		if public == nil {
+			public = make(Details, len(details))
+		}
+		public[k] = v
+	}
+	switch b := e.Body.(type) {
+	case Response:
+		body := b.Error.(map[string]interface{})
+		body["details"] = public
+		body["reference"] = reference
+	case ProblemDetails:
+		b.Details = public
+		b.Reference = reference
+		e.Body = b
+	}
+	c.Header(ErrorReferenceHeader, reference)
+}
+
+func requestID(c *gin.Context) string {
+	if id := c.Writer.Header().Get(RequestIDHeader); id != "" {
+		return id
+	}
+	if c.Request != nil {
+		return c.GetHeader(RequestIDHeader)
+	}
+	return ""
+}
+
+func encodeErrorReference(key []byte, ref ErrorReference) (string, error) {
+	gcm, err := referenceCipher(key)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(ref)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return errorReferencePrefix + base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func referenceKey(secret []byte) []byte {
+	sum := sha256.Sum256(append([]byte("responseutils error reference\x00"), secret...))
+	return sum[:]
+}
+
+func referenceCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
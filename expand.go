@@ -0,0 +1,349 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const expandersKey = "responseutils.expanders"
+
+// ExpandParam is the query parameter listing the relations to expand
+const ExpandParam = "expand"
+
+// ExpandFunc loads a relation for a batch of parent resources, returning one
+// value per parent in the same order, or nil for parents without one. All
+// parents of a relation are loaded in one call, so expanders can fetch them
+// with a single query instead of one per item.
+type ExpandFunc func(c *gin.Context, parents []interface{}) ([]interface{}, error)
+
+// ExpandOptions limit the expansions a request may ask for
+type ExpandOptions struct {
+	// MaxDepth limits nesting such as "order.customer.address"; 0 means 3
+	MaxDepth int
+	// MaxRelations limits the relations expanded per request; 0 means 10
+	MaxRelations int
+	// BeforeLoad is called before each batch load, e.g. to record metrics or
+	// enforce a query budget. Returning an error aborts the response with it.
+	BeforeLoad func(c *gin.Context, relation string, parents int) error
+}
+
+// Expanders resolve the expand= query parameter of the routes they are used on
+type Expanders struct {
+	opts  ExpandOptions
+	funcs map[string]ExpandFunc
+}
+
+// NewExpanders creates an empty set of expanders
+func NewExpanders(opts ExpandOptions) *Expanders {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 3
+	}
+	if opts.MaxRelations <= 0 {
+		opts.MaxRelations = 10
+	}
+	return &Expanders{opts: opts, funcs: map[string]ExpandFunc{}}
+}
+
+// Register sets the expander for a relation. Nested relations are registered
+// by their full path, e.g. "customer.address" expands the address of each
+// expanded customer.
+func (x *Expanders) Register(relation string, fn ExpandFunc) *Expanders {
+	x.funcs[relation] = fn
+	return x
+}
+
+// Relations returns the registered relations in sorted order
+func (x *Expanders) Relations() []string {
+	names := make([]string, 0, len(x.funcs))
+	for name := range x.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Middleware makes the expanders available to OKResponse, SuccessResponse
+// and ListResponseWithPagination on the routes it is used on
+func (x *Expanders) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(expandersKey, x)
+		c.Next()
+	}
+}
+
+// RequestedExpansions returns the relations named by the expand= parameter,
+// which may be repeated or comma-separated
+func RequestedExpansions(c *gin.Context) []string {
+	var relations []string
+	for _, v := range c.QueryArray(ExpandParam) {
+		for _, rel := range strings.Split(v, ",") {
+			if rel = strings.TrimSpace(rel); rel != "" {
+				relations = append(relations, rel)
+			}
+		}
+	}
+	return relations
+}
+
+func InvalidExpansion(relation, reason string, allowed []string) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidExpansion,
+		fmt.Sprintf("Cannot expand '%s': %s", relation, reason),
+		http.StatusBadRequest,
+	).WithDetails("expand", relation).
+		WithDetails("allowed", allowed)
+}
+
+// plan validates the requested relations and returns them with their
+// parent relations, ordered so parents are expanded before their children
+func (x *Expanders) plan(requested []string) ([]string, error) {
+	seen := map[string]bool{}
+	var paths []string
+	for _, rel := range requested {
+		segments := strings.Split(rel, ".")
+		if len(segments) > x.opts.MaxDepth {
+			return nil, InvalidExpansion(rel, fmt.Sprintf("expansions may be nested at most %d levels", x.opts.MaxDepth), x.Relations())
+		}
+		for i := range segments {
+			path := strings.Join(segments[:i+1], ".")
+			if seen[path] {
+				continue
+			}
+			if x.funcs[path] == nil {
+				return nil, InvalidExpansion(rel, "unknown relation", x.Relations())
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) > x.opts.MaxRelations {
+		return nil, InvalidExpansion(strings.Join(requested, ","), fmt.Sprintf("at most %d relations may be expanded", x.opts.MaxRelations), x.Relations())
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return strings.Count(paths[i], ".") < strings.Count(paths[j], ".")
+	})
+	return paths, nil
+}
+
+// applyExpansions resolves the request's expand= parameter against data,
+// returning data with the expanded relations inlined
+func applyExpansions(c *gin.Context, data interface{}) (interface{}, error) {
+	v, ok := c.Get(expandersKey)
+	if !ok || data == nil {
+		return data, nil
+	}
+	requested := RequestedExpansions(c)
+	if len(requested) == 0 {
+		return data, nil
+	}
+
+	x := v.(*Expanders)
+	paths, err := x.plan(requested)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	var roots []*expandNode
+	if list := reflect.ValueOf(data); list.Kind() == reflect.Slice || list.Kind() == reflect.Array {
+		roots = make([]*expandNode, list.Len())
+		for i := range roots {
+			roots[i] = &expandNode{value: list.Index(i).Interface()}
+		}
+		result = roots
+	} else {
+		roots = []*expandNode{{value: data}}
+		result = roots[0]
+	}
+
+	nodes := map[string][]*expandNode{"": roots}
+	for _, path := range paths {
+		parentPath, name := "", path
+		if i := strings.LastIndexByte(path, '.'); i >= 0 {
+			parentPath, name = path[:i], path[i+1:]
+		}
+
+		var parents []*expandNode
+		for _, n := range nodes[parentPath] {
+			if n.value != nil {
+				parents = append(parents, n)
+			}
+		}
+		if len(parents) == 0 {
+			continue
+		}
+
+		if x.opts.BeforeLoad != nil {
+			if err := x.opts.BeforeLoad(c, path, len(parents)); err != nil {
+				return nil, err
+			}
+		}
+		values := make([]interface{}, len(parents))
+		for i, n := range parents {
+			values[i] = n.parentValue()
+		}
+		related, err := x.funcs[path](c, values)
+		if err != nil {
+			return nil, err
+		}
+		if len(related) != len(parents) {
+			return nil, fmt.Errorf("expander for %q returned %d values for %d parents", path, len(related), len(parents))
+		}
+
+		for i, rel := range related {
+			if rel == nil {
+				parents[i].set(name, nil)
+				continue
+			}
+			if list := reflect.ValueOf(rel); list.Kind() == reflect.Slice {
+				children := make([]*expandNode, list.Len())
+				for j := range children {
+					children[j] = &expandNode{value: list.Index(j).Interface()}
+				}
+				parents[i].set(name, children)
+				nodes[path] = append(nodes[path], children...)
+				continue
+			}
+			child := &expandNode{value: rel}
+			parents[i].set(name, child)
+			nodes[path] = append(nodes[path], child)
+		}
+	}
+	return result, nil
+}
+
+// expandNode is a resource with its expanded relations, which replace the
+// members of the same name in its serialized form
+type expandNode struct {
+	value    interface{}
+	names    []string
+	expanded map[string]interface{}
+}
+
+func (n *expandNode) set(name string, v interface{}) {
+	if n.expanded == nil {
+		n.expanded = map[string]interface{}{}
+	}
+	if _, ok := n.expanded[name]; !ok {
+		n.names = append(n.names, name)
+	}
+	n.expanded[name] = v
+}
+
+// parentValue returns the resource passed to expanders
+func (n *expandNode) parentValue() interface{} {
+	if t, ok := n.value.(tombstone); ok {
+		return t.item
+	}
+	return n.value
+}
+
+// MarshalJSON implements json.Marshaler. The expanded relations are spliced
+// into the encoded resource, in place of any member of the same name, so the
+// resource's own members keep their order and encoding.
+func (n *expandNode) MarshalJSON() ([]byte, error) {
+	data, err := CurrentEncoder().Marshal(n.value)
+	if err != nil || len(n.names) == 0 {
+		return data, err
+	}
+
+	members, ok := objectMembers(data)
+	if !ok {
+		// Not an object, so there is nowhere to inline the relations
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	written := make(map[string]bool, len(n.names))
+	for _, m := range members {
+		_, expanded := n.expanded[m.key]
+		if expanded && written[m.key] {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		if !expanded {
+			buf.Write(m.raw)
+			continue
+		}
+		if err := n.writeMember(&buf, m.key); err != nil {
+			return nil, err
+		}
+		written[m.key] = true
+	}
+	for _, name := range n.names {
+		if written[name] {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		if err := n.writeMember(&buf, name); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeMember writes the expanded relation name as an object member
+func (n *expandNode) writeMember(buf *bytes.Buffer, name string) error {
+	key, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+	value, err := CurrentEncoder().Marshal(n.expanded[name])
+	if err != nil {
+		return err
+	}
+	buf.Write(key)
+	buf.WriteByte(':')
+	buf.Write(bytes.TrimSpace(value))
+	return nil
+}
+
+// objectMember is a member of an encoded object: its decoded key and its
+// bytes as encoded, key and value included
+type objectMember struct {
+	key string
+	raw []byte
+}
+
+// objectMembers splits an encoded JSON object into its members, reporting
+// false when data is not an object
+func objectMembers(data []byte) ([]objectMember, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	var members []objectMember
+	for dec.More() {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		raw := bytes.TrimLeft(data[start:dec.InputOffset()], " \t\r\n,")
+		members = append(members, objectMember{key: key, raw: raw})
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return nil, false
+	}
+	return members, true
+}
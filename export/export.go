@@ -0,0 +1,327 @@
+// Package export runs long exports as background jobs: starting one answers
+// 202 Accepted with the job, progress is streamed as server-sent events, and
+// the finished file is downloaded with range requests and a checksum.
+//
+// Results are written to a pluggable Storage; MemoryStorage and DirStorage
+// are provided.
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// Status is the lifecycle state of an export job
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Done reports whether the job has finished, successfully or not
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Job describes an export job
+// @Description Export job structure
+type Job struct {
+	ID          string    `json:"id" example:"0b6f6c3e-3c1a-4c55-9e8d-4df1f5b2b1e2"`
+	Status      Status    `json:"status" example:"running"`
+	Percent     int       `json:"percent" example:"40"`
+	Error       string    `json:"error,omitempty"`
+	Size        int64     `json:"size,omitempty" example:"1048576"`
+	Checksum    string    `json:"checksum,omitempty" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at,omitzero"`
+	StatusURL   string    `json:"status_url" example:"/exports/0b6f6c3e-3c1a-4c55-9e8d-4df1f5b2b1e2"`
+	EventsURL   string    `json:"events_url" example:"/exports/0b6f6c3e-3c1a-4c55-9e8d-4df1f5b2b1e2/events"`
+	DownloadURL string    `json:"download_url,omitempty" example:"/exports/0b6f6c3e-3c1a-4c55-9e8d-4df1f5b2b1e2/download"`
+}
+
+// Exporter writes an export to w, reporting its progress (0-100) as it goes.
+// ctx is cancelled when the manager shuts down, not when the starting request ends.
+type Exporter func(ctx context.Context, w io.Writer, progress func(percent int)) error
+
+// Options configure a Manager
+type Options struct {
+	// BasePath is the path the handlers are registered under; defaults to "/exports"
+	BasePath string
+	// ContentType of the downloaded file; defaults to application/octet-stream
+	ContentType string
+	// FileName suggested for the download; defaults to "export"
+	FileName string
+	// Retention is how long finished jobs and their results are kept; 0 keeps them forever
+	Retention time.Duration
+}
+
+// Manager runs export jobs and serves their status, events and results
+type Manager struct {
+	storage Storage
+	opts    Options
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu   sync.Mutex
+	jobs map[string]*entry
+}
+
+type entry struct {
+	job     Job
+	changed chan struct{}
+}
+
+// NewManager creates a manager storing results in storage
+func NewManager(storage Storage, opts Options) *Manager {
+	if opts.BasePath == "" {
+		opts.BasePath = "/exports"
+	}
+	opts.BasePath = strings.TrimRight(opts.BasePath, "/")
+	if opts.ContentType == "" {
+		opts.ContentType = "application/octet-stream"
+	}
+	if opts.FileName == "" {
+		opts.FileName = "export"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{storage: storage, opts: opts, ctx: ctx, cancel: cancel, jobs: map[string]*entry{}}
+}
+
+// Close cancels running exports
+func (m *Manager) Close() {
+	m.cancel()
+}
+
+// Register registers the status, events and download handlers on r
+func (m *Manager) Register(r gin.IRoutes) {
+	r.GET(m.opts.BasePath+"/:id", m.StatusHandler())
+	r.GET(m.opts.BasePath+"/:id/events", m.EventsHandler())
+	r.GET(m.opts.BasePath+"/:id/download", m.DownloadHandler())
+	r.HEAD(m.opts.BasePath+"/:id/download", m.DownloadHandler())
+}
+
+// Start starts an export in the background and answers 202 Accepted with the
+// job, whose Location is its status resource
+func (m *Manager) Start(c *gin.Context, fn Exporter) {
+	job := m.create()
+	go m.run(job.ID, fn)
+	responseutils.AcceptedResponse(c, job, job.StatusURL)
+}
+
+// Job returns the current state of a job
+func (m *Manager) Job(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.job, true
+}
+
+// StatusHandler serves a job's current state
+func (m *Manager) StatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := m.Job(c.Param("id"))
+		if !ok {
+			responseutils.ErrorResponse(c, responseutils.NotFound("Export"))
+			return
+		}
+		responseutils.OKResponse(c, job, "")
+	}
+}
+
+// EventsHandler streams a job's state as server-sent events: a "progress"
+// event on every change, ending with a "succeeded" or "failed" event
+func (m *Manager) EventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		job, changed, ok := m.watch(id)
+		if !ok {
+			responseutils.ErrorResponse(c, responseutils.NotFound("Export"))
+			return
+		}
+
+		responseutils.StartEventStream(c)
+		for {
+			event := "progress"
+			if job.Status.Done() {
+				event = string(job.Status)
+			}
+			if err := responseutils.StreamEvent(c, event, job); err != nil || job.Status.Done() {
+				return
+			}
+
+			select {
+			case <-changed:
+			case <-c.Request.Context().Done():
+				return
+			}
+			job, changed, _ = m.watch(id)
+		}
+	}
+}
+
+// DownloadHandler serves a finished job's result. Range and If-Range
+// requests are supported, the ETag is the result's checksum and Repr-Digest
+// carries its SHA-256 so partial downloads can be verified once complete.
+func (m *Manager) DownloadHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := m.Job(c.Param("id"))
+		if !ok {
+			responseutils.ErrorResponse(c, responseutils.NotFound("Export"))
+			return
+		}
+		if job.Status != StatusSucceeded {
+			responseutils.ErrorResponse(c, responseutils.Conflict("Export is not ready for download").
+				WithDetails("status", job.Status))
+			return
+		}
+
+		f, err := m.storage.Open(c.Request.Context(), job.ID)
+		if err != nil {
+			responseutils.ErrorResponse(c, err)
+			return
+		}
+		defer f.Close()
+
+		sum, _ := hex.DecodeString(strings.TrimPrefix(job.Checksum, "sha256:"))
+		c.Header("Content-Type", m.opts.ContentType)
+		c.Header("Content-Disposition", `attachment; filename="`+m.opts.FileName+`"`)
+		c.Header("ETag", `"`+job.Checksum+`"`)
+		c.Header("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+		http.ServeContent(c.Writer, c.Request, m.opts.FileName, job.CompletedAt, f)
+	}
+}
+
+func (m *Manager) create() Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+
+	id := uuid.NewString()
+	base := m.opts.BasePath + "/" + id
+	job := Job{
+		ID:        id,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+		StatusURL: base,
+		EventsURL: base + "/events",
+	}
+	m.jobs[id] = &entry{job: job, changed: make(chan struct{})}
+	return job
+}
+
+func (m *Manager) run(id string, fn Exporter) {
+	m.update(id, func(j *Job) { j.Status = StatusRunning })
+
+	fail := func(err error) {
+		m.update(id, func(j *Job) {
+			j.Status = StatusFailed
+			j.Error = err.Error()
+			j.CompletedAt = time.Now().UTC()
+		})
+	}
+
+	w, err := m.storage.Create(m.ctx, id)
+	if err != nil {
+		fail(err)
+		return
+	}
+	cw := &countingWriter{w: w, h: sha256.New()}
+	err = fn(m.ctx, cw, func(percent int) {
+		m.update(id, func(j *Job) { j.Percent = min(max(percent, 0), 100) })
+	})
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.storage.Delete(context.Background(), id)
+		fail(err)
+		return
+	}
+
+	m.update(id, func(j *Job) {
+		j.Status = StatusSucceeded
+		j.Percent = 100
+		j.Size = cw.n
+		j.Checksum = "sha256:" + hex.EncodeToString(cw.h.Sum(nil))
+		j.CompletedAt = time.Now().UTC()
+		j.DownloadURL = j.StatusURL + "/download"
+	})
+}
+
+// update modifies a job and wakes its event streams
+func (m *Manager) update(id string, fn func(*Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	before := e.job
+	fn(&e.job)
+	if e.job == before {
+		return
+	}
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// watch returns a job and a channel closed on its next change
+func (m *Manager) watch(id string) (Job, <-chan struct{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, nil, false
+	}
+	return e.job, e.changed, true
+}
+
+// expire removes finished jobs past their retention; m.mu must be held
+func (m *Manager) expire() {
+	if m.opts.Retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-m.opts.Retention)
+	for id, e := range m.jobs {
+		if e.job.Status.Done() && e.job.CompletedAt.Before(cutoff) {
+			delete(m.jobs, id)
+			go m.storage.Delete(context.Background(), id)
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.h.Write(p[:n])
+	w.n += int64(n)
+	return n, err
+}
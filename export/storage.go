@@ -0,0 +1,129 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// Storage stores export results, e.g. on disk or in an object store
+type Storage interface {
+	// Create returns a writer for a job's result, committed on Close
+	Create(ctx context.Context, id string) (io.WriteCloser, error)
+	// Open opens a job's result for reading
+	Open(ctx context.Context, id string) (io.ReadSeekCloser, error)
+	// Delete removes a job's result
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStorage keeps results in memory, for tests and small exports
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage creates an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: map[string][]byte{}}
+}
+
+// Create implements Storage
+func (s *MemoryStorage) Create(_ context.Context, id string) (io.WriteCloser, error) {
+	return &memoryFile{storage: s, id: id}, nil
+}
+
+// Open implements Storage
+func (s *MemoryStorage) Open(_ context.Context, id string) (io.ReadSeekCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[id]
+	if !ok {
+		return nil, responseutils.NotFound("Export result")
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+// Delete implements Storage
+func (s *MemoryStorage) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, id)
+	return nil
+}
+
+type memoryFile struct {
+	bytes.Buffer
+	storage *MemoryStorage
+	id      string
+}
+
+func (f *memoryFile) Close() error {
+	f.storage.mu.Lock()
+	defer f.storage.mu.Unlock()
+
+	f.storage.files[f.id] = f.Bytes()
+	return nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+// DirStorage stores results as files in a directory
+type DirStorage struct {
+	Dir string
+}
+
+// Create implements Storage. Results are written to a temporary file and
+// renamed on Close, so partial results are never served.
+func (s DirStorage) Create(_ context.Context, id string) (io.WriteCloser, error) {
+	f, err := os.CreateTemp(s.Dir, id+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{File: f, path: s.path(id)}, nil
+}
+
+// Open implements Storage
+func (s DirStorage) Open(_ context.Context, id string) (io.ReadSeekCloser, error) {
+	f, err := os.Open(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, responseutils.NotFound("Export result")
+	}
+	return f, err
+}
+
+// Delete implements Storage
+func (s DirStorage) Delete(_ context.Context, id string) error {
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s DirStorage) path(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id))
+}
+
+type dirFile struct {
+	*os.File
+	path string
+}
+
+func (f *dirFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), f.path)
+}
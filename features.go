@@ -0,0 +1,128 @@
+package responseutils
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnvelopeFeature identifies an envelope feature that can be rolled out progressively
+type EnvelopeFeature string
+
+const (
+	// FeatureMeta controls the "meta" section
+	FeatureMeta EnvelopeFeature = "envelope.meta"
+	// FeatureLinks controls the "links" section
+	FeatureLinks EnvelopeFeature = "envelope.links"
+	// FeatureProblemDetails sends errors as RFC 7807 problem details
+	FeatureProblemDetails EnvelopeFeature = "envelope.problem_details"
+	// FeatureDeprecationWarning adds a Warning header to responses containing deprecated fields
+	FeatureDeprecationWarning EnvelopeFeature = "envelope.deprecation_warning"
+)
+
+// featureDefaults are used for features the flag provider does not
+// configure, or when no provider is set
+var featureDefaults = map[EnvelopeFeature]bool{
+	FeatureMeta:               true,
+	FeatureLinks:              true,
+	FeatureProblemDetails:     false,
+	FeatureDeprecationWarning: false,
+}
+
+// FlagProvider evaluates envelope feature flags for a request. known is
+// false for features the provider does not configure, which keep their
+// default.
+type FlagProvider interface {
+	Enabled(c *gin.Context, feature EnvelopeFeature) (enabled, known bool)
+}
+
+// FlagProviderFunc adapts a function to the FlagProvider interface
+type FlagProviderFunc func(c *gin.Context, feature EnvelopeFeature) (enabled, known bool)
+
+// Enabled implements FlagProvider
+func (f FlagProviderFunc) Enabled(c *gin.Context, feature EnvelopeFeature) (bool, bool) {
+	return f(c, feature)
+}
+
+var (
+	flagsMu      sync.RWMutex
+	flagProvider FlagProvider
+)
+
+// SetFlagProvider sets the provider used to evaluate envelope features.
+// Passing nil restores the defaults: meta and links on, problem details and
+// deprecation warnings off.
+func SetFlagProvider(p FlagProvider) {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+
+	flagProvider = p
+}
+
+// FeatureEnabled reports whether an envelope feature is enabled for the request
+func FeatureEnabled(c *gin.Context, feature EnvelopeFeature) bool {
+	flagsMu.RLock()
+	p := flagProvider
+	flagsMu.RUnlock()
+
+	if p != nil {
+		if enabled, known := p.Enabled(c, feature); known {
+			return enabled
+		}
+	}
+	return featureDefaults[feature]
+}
+
+// RolloutFlags is a FlagProvider enabling features for specific tenants and
+// for a stable percentage of requests. Features it lists in neither map keep
+// their default.
+type RolloutFlags struct {
+	// Percentages enables a feature for the given percentage (0-100) of keys
+	Percentages map[EnvelopeFeature]int
+	// Tenants enables a feature for the listed tenants regardless of percentage
+	Tenants map[EnvelopeFeature][]string
+	// Key returns the rollout bucketing key; defaults to the tenant from the
+	// response defaults or TenantMiddleware, falling back to the client IP
+	Key func(c *gin.Context) string
+}
+
+// Enabled implements FlagProvider
+func (r *RolloutFlags) Enabled(c *gin.Context, feature EnvelopeFeature) (bool, bool) {
+	tenants, listed := r.Tenants[feature]
+	tenant := requestTenant(c)
+	for _, t := range tenants {
+		if tenant != "" && t == tenant {
+			return true, true
+		}
+	}
+
+	pct, ok := r.Percentages[feature]
+	if !ok {
+		return false, listed
+	}
+
+	key := ""
+	if r.Key != nil {
+		key = r.Key(c)
+	} else if tenant != "" {
+		key = tenant
+	} else if c.Request != nil {
+		key = c.ClientIP()
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(string(feature) + ":" + key))
+	return int(h.Sum32()%100) < pct, true
+}
+
+func requestTenant(c *gin.Context) string {
+	if c.Request == nil {
+		return ""
+	}
+	if d, _ := ResponseDefaultsFromContext(c.Request.Context()); d.Tenant != "" {
+		return d.Tenant
+	}
+	tenant, _ := TenantFromContext(c.Request.Context())
+	return tenant
+}
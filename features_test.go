@@ -0,0 +1,40 @@
+package responseutils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFlagProviderKeepsDefaultsForUnconfiguredFeatures(t *testing.T) {
+	SetFlagProvider(&RolloutFlags{
+		Percentages: map[EnvelopeFeature]int{FeatureProblemDetails: 100},
+	})
+	defer SetFlagProvider(nil)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	if !FeatureEnabled(c, FeatureProblemDetails) {
+		t.Error("FeatureProblemDetails disabled, want enabled by the rollout")
+	}
+	for _, f := range []EnvelopeFeature{FeatureMeta, FeatureLinks} {
+		if !FeatureEnabled(c, f) {
+			t.Errorf("%s disabled, want its default", f)
+		}
+	}
+	if FeatureEnabled(c, FeatureDeprecationWarning) {
+		t.Error("FeatureDeprecationWarning enabled, want its default")
+	}
+}
+
+func TestRolloutFlagsTenantListIsConfigured(t *testing.T) {
+	flags := &RolloutFlags{Tenants: map[EnvelopeFeature][]string{FeatureMeta: {"acme"}}}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	if enabled, known := flags.Enabled(c, FeatureMeta); enabled || !known {
+		t.Errorf("Enabled = %v, %v, want false, true for a tenant not listed", enabled, known)
+	}
+}
@@ -0,0 +1,131 @@
+package responseutils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const fetchMetadataKey = "responseutils.fetch_metadata"
+
+// DefaultUrgency is the RFC 9218 urgency of requests without a Priority header
+const DefaultUrgency = 3
+
+// FetchMetadata holds a request's Sec-Fetch-* fetch metadata and its RFC 9218
+// Priority. Headers the client did not send are left empty.
+type FetchMetadata struct {
+	// Site is Sec-Fetch-Site: same-origin, same-site, cross-site or none
+	Site string
+	// Mode is Sec-Fetch-Mode, e.g. cors, navigate or no-cors
+	Mode string
+	// Dest is Sec-Fetch-Dest, e.g. empty for fetch() calls or document
+	Dest string
+	// User is Sec-Fetch-User, set for navigations triggered by the user
+	User bool
+	// Purpose is Sec-Purpose, e.g. "prefetch" for speculative fetches
+	Purpose string
+	// Urgency is the Priority urgency from 0 (highest) to 7 (lowest)
+	Urgency int
+	// Incremental is the Priority incremental flag
+	Incremental bool
+}
+
+// Background reports whether the request is a low-priority background fetch:
+// a prefetch or an urgency of 5 or lower priority, so a BFF can send a
+// leaner payload
+func (m FetchMetadata) Background() bool {
+	return m.Urgency >= 5 || strings.Contains(m.Purpose, "prefetch")
+}
+
+// PriorityMeta is the processing priority echoed in meta.priority
+// @Description Request processing priority
+type PriorityMeta struct {
+	Urgency     int  `json:"urgency" example:"3"`
+	Incremental bool `json:"incremental" example:"false"`
+	Background  bool `json:"background" example:"false"`
+}
+
+// FetchMetadataOptions configure FetchMetadataMiddleware
+type FetchMetadataOptions struct {
+	// EchoPriority adds the processing priority to the meta of responses
+	EchoPriority bool
+}
+
+// FetchMetadataMiddleware parses the fetch metadata and priority of every
+// request, see RequestFetchMetadata
+func FetchMetadataMiddleware(opts FetchMetadataOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m := ParseFetchMetadata(c)
+		c.Set(fetchMetadataKey, fetchMetadata{m: m, echo: opts.EchoPriority})
+		c.Next()
+	}
+}
+
+type fetchMetadata struct {
+	m    FetchMetadata
+	echo bool
+}
+
+// RequestFetchMetadata returns the fetch metadata parsed for this request by
+// FetchMetadataMiddleware
+func RequestFetchMetadata(c *gin.Context) (FetchMetadata, bool) {
+	v, ok := c.Get(fetchMetadataKey)
+	if !ok {
+		return FetchMetadata{Urgency: DefaultUrgency}, false
+	}
+	return v.(fetchMetadata).m, true
+}
+
+// IsBackgroundFetch reports whether the request is a low-priority background
+// fetch, parsing the headers when FetchMetadataMiddleware is not installed
+func IsBackgroundFetch(c *gin.Context) bool {
+	m, ok := RequestFetchMetadata(c)
+	if !ok {
+		m = ParseFetchMetadata(c)
+	}
+	return m.Background()
+}
+
+// ParseFetchMetadata reads the Sec-Fetch-*, Sec-Purpose and Priority headers
+// of a request
+func ParseFetchMetadata(c *gin.Context) FetchMetadata {
+	m := FetchMetadata{
+		Site:    strings.ToLower(c.GetHeader("Sec-Fetch-Site")),
+		Mode:    strings.ToLower(c.GetHeader("Sec-Fetch-Mode")),
+		Dest:    strings.ToLower(c.GetHeader("Sec-Fetch-Dest")),
+		User:    c.GetHeader("Sec-Fetch-User") == "?1",
+		Purpose: strings.ToLower(c.GetHeader("Sec-Purpose")),
+	}
+	m.Urgency, m.Incremental = parsePriority(c.GetHeader("Priority"))
+	return m
+}
+
+// parsePriority parses an RFC 9218 Priority header such as "u=5, i",
+// ignoring invalid members
+func parsePriority(header string) (urgency int, incremental bool) {
+	urgency = DefaultUrgency
+	for _, member := range strings.Split(header, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(member), "=")
+		switch key {
+		case "u":
+			if u, err := strconv.Atoi(value); err == nil && u >= 0 && u <= 7 {
+				urgency = u
+			}
+		case "i":
+			incremental = !hasValue || value == "?1"
+		}
+	}
+	return urgency, incremental
+}
+
+// applyFetchMetadata echoes the processing priority in meta.priority when
+// FetchMetadataMiddleware was installed with EchoPriority
+func applyFetchMetadata(c *gin.Context, e *Envelope) {
+	v, ok := c.Get(fetchMetadataKey)
+	if !ok || !v.(fetchMetadata).echo {
+		return
+	}
+	m := v.(fetchMetadata).m
+	e.SetMeta("priority", PriorityMeta{Urgency: m.Urgency, Incremental: m.Incremental, Background: m.Background()})
+}
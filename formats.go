@@ -0,0 +1,139 @@
+package responseutils
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Field error codes returned by the format validators
+const (
+	FieldCodeInvalidSlug     = "INVALID_SLUG"
+	FieldCodeInvalidEmail    = "INVALID_EMAIL"
+	FieldCodeInvalidPhone    = "INVALID_PHONE"
+	FieldCodeInvalidCountry  = "INVALID_COUNTRY"
+	FieldCodeInvalidCurrency = "INVALID_CURRENCY"
+)
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+)
+
+// ISO 3166-1 alpha-2 country codes
+var countryCodes = codeSet(`
+AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL
+BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV
+CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD
+GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM
+IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK
+LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW
+MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR
+PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS
+ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY
+UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+`)
+
+// ISO 4217 active currency codes
+var currencyCodes = codeSet(`
+AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUP CVE CZK
+DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL
+HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT
+LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR
+MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF
+SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP
+TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU
+XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW ZWG
+`)
+
+func codeSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, code := range strings.Fields(list) {
+		set[code] = true
+	}
+	return set
+}
+
+// ValidateSlug checks value is a lowercase, hyphen-separated slug such as "summer-sale-2024"
+func ValidateSlug(field, value string) *FieldError {
+	if slugPattern.MatchString(value) {
+		return nil
+	}
+	return &FieldError{
+		Field:   field,
+		Message: "must contain only lowercase letters, digits and single hyphens",
+		Code:    FieldCodeInvalidSlug,
+	}
+}
+
+// ValidateEmail checks value is a bare email address, without a display name
+func ValidateEmail(field, value string) *FieldError {
+	if addr, err := mail.ParseAddress(value); err == nil && addr.Address == value && addr.Name == "" {
+		return nil
+	}
+	return &FieldError{
+		Field:   field,
+		Message: "must be a valid email address",
+		Code:    FieldCodeInvalidEmail,
+	}
+}
+
+// ValidateE164 checks value is a phone number in E.164 format, e.g. "+442071838750"
+func ValidateE164(field, value string) *FieldError {
+	if e164Pattern.MatchString(value) {
+		return nil
+	}
+	return &FieldError{
+		Field:   field,
+		Message: "must be a phone number in E.164 format, e.g. +442071838750",
+		Code:    FieldCodeInvalidPhone,
+	}
+}
+
+// ValidateCountryCode checks value is an uppercase ISO 3166-1 alpha-2 country code
+func ValidateCountryCode(field, value string) *FieldError {
+	if countryCodes[value] {
+		return nil
+	}
+	return &FieldError{
+		Field:   field,
+		Message: "must be an ISO 3166-1 alpha-2 country code, e.g. GB",
+		Code:    FieldCodeInvalidCountry,
+	}
+}
+
+// ValidateCurrencyCode checks value is an uppercase ISO 4217 currency code
+func ValidateCurrencyCode(field, value string) *FieldError {
+	if currencyCodes[value] {
+		return nil
+	}
+	return &FieldError{
+		Field:   field,
+		Message: "must be an ISO 4217 currency code, e.g. GBP",
+		Code:    FieldCodeInvalidCurrency,
+	}
+}
+
+// ValidateFields collects the failed checks into a VALIDATION_ERROR, returning
+// nil when every check passed
+//
+//	if err := responseutils.ValidateFields(
+//		responseutils.ValidateSlug("slug", req.Slug),
+//		responseutils.ValidateEmail("email", req.Email),
+//	); err != nil {
+//		responseutils.ErrorResponse(c, err)
+//		return
+//	}
+func ValidateFields(checks ...*FieldError) *ResponseError {
+	var fields []FieldError
+	for _, fe := range checks {
+		if fe != nil {
+			fields = append(fields, *fe)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return ValidationError("Request validation failed").SetFieldErrors(fields...)
+}
@@ -0,0 +1,274 @@
+// Package fuzz provides fuzz targets for the parts of the package that
+// handle untrusted input: cursor and token decoding, details serialization,
+// Accept header negotiation, decoding envelopes as a client does, and error
+// envelope writing.
+//
+// The package's own tests run every target under Go native fuzzing, with
+// the seed corpora in testdata/fuzz:
+//
+//	go test -fuzz FuzzSearchCursor ./fuzz
+//
+// Targets can also run from a test in another module, seeded with their
+// built-in seeds:
+//
+//	func FuzzSearchCursor(f *testing.F) { fuzz.Lookup("SearchCursor").Fuzz(f) }
+//
+// The respfuzz command gives every target a quick smoke run:
+//
+//	go run ./cmd/respfuzz -duration 30s
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Target is a named fuzz target. F returns an error when an input breaks
+// one of the target's invariants; a panic is a failure too.
+type Target struct {
+	Name  string
+	Seeds [][]byte
+	F     func(data []byte) error
+}
+
+// Fuzz runs the target under Go native fuzzing, seeded with its corpus
+func (t Target) Fuzz(f *testing.F) {
+	for _, seed := range t.Seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(tt *testing.T, data []byte) {
+		if err := t.F(data); err != nil {
+			tt.Fatalf("%s: %v", t.Name, err)
+		}
+	})
+}
+
+// Targets returns every fuzz target
+func Targets() []Target {
+	return []Target{
+		{"SearchCursor", cursorSeeds(), searchCursor},
+		{"SyncToken", cursorSeeds(), syncToken},
+		{"ErrorReference", referenceSeeds(), errorReference},
+		{"Details", detailsSeeds(), details},
+		{"NegotiateMediaType", acceptSeeds(), negotiateMediaType},
+		{"EnvelopeDecoder", envelopeSeeds(), envelopeDecoder},
+		{"ErrorWriter", detailsSeeds(), errorWriter},
+	}
+}
+
+// Lookup returns the target with the given name, panicking for unknown names
+func Lookup(name string) Target {
+	for _, t := range Targets() {
+		if t.Name == name {
+			return t
+		}
+	}
+	panic(fmt.Sprintf("fuzz: unknown target %q", name))
+}
+
+// searchCursor checks that a decoded cursor survives re-encoding
+func searchCursor(data []byte) error {
+	sc, err := responseutils.DecodeSearchCursor(string(data))
+	if err != nil {
+		if err.Code != responseutils.ErrCodeInvalidSearchCursor {
+			return fmt.Errorf("unexpected error code %s", err.Code)
+		}
+		return nil
+	}
+	again, err := responseutils.DecodeSearchCursor(responseutils.EncodeSearchCursor(sc))
+	if err != nil {
+		return fmt.Errorf("re-encoded cursor does not decode: %v", err)
+	}
+	if !reflect.DeepEqual(sc, again) {
+		return fmt.Errorf("cursor changed on re-encoding: %#v != %#v", sc, again)
+	}
+	return nil
+}
+
+// syncToken checks that decoding never panics and that decoded positions
+// survive re-encoding
+func syncToken(data []byte) error {
+	st, err := responseutils.DecodeSyncToken(string(data))
+	if err != nil || st.IsZero() {
+		return nil
+	}
+	again, err := responseutils.DecodeSyncToken(responseutils.EncodeSyncToken(st.Position))
+	if err != nil {
+		return fmt.Errorf("re-encoded token does not decode: %v", err)
+	}
+	if again.Position != st.Position {
+		return fmt.Errorf("position changed on re-encoding: %q != %q", st.Position, again.Position)
+	}
+	return nil
+}
+
+var referenceSecret = []byte("fuzz")
+
+// errorReference checks that only references issued with the key decode.
+// Other spellings of the issued reference, e.g. with line breaks, decode to
+// the same reference and are fine.
+func errorReference(data []byte) error {
+	ref, err := responseutils.DecodeErrorReference(referenceSecret, string(data))
+	if err != nil {
+		return nil
+	}
+	if ref == issuedReference {
+		return nil
+	}
+	return fmt.Errorf("forged reference decoded: %+v", ref)
+}
+
+// details checks that details from untrusted JSON serialize to sorted,
+// valid JSON holding the same values
+func details(data []byte) error {
+	var d responseutils.Details
+	if json.Unmarshal(data, &d) != nil {
+		return nil
+	}
+	out, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("details do not serialize: %v", err)
+	}
+	var back responseutils.Details
+	if err := json.Unmarshal(out, &back); err != nil {
+		return fmt.Errorf("serialized details are not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(d, back) {
+		return fmt.Errorf("details changed on serialization: %s", out)
+	}
+	return nil
+}
+
+var offers = []string{"application/json", "application/problem+json", "text/csv", "application/x-ndjson"}
+
+// negotiateMediaType checks that negotiation only ever picks an offer
+func negotiateMediaType(data []byte) error {
+	accept := string(data)
+	got, ok := responseutils.NegotiateMediaType(accept, offers...)
+	if !ok {
+		if got != "" {
+			return fmt.Errorf("failed negotiation returned %q", got)
+		}
+		return nil
+	}
+	offered := false
+	for _, o := range offers {
+		offered = offered || o == got
+	}
+	if !offered {
+		return fmt.Errorf("negotiated %q, which was not offered", got)
+	}
+	return nil
+}
+
+// errorWriter checks that an error carrying untrusted details is written
+// as a valid envelope with its code and message intact
+func errorWriter(data []byte) error {
+	var d responseutils.Details
+	if json.Unmarshal(data, &d) != nil {
+		return nil
+	}
+	message := string(data)
+
+	body := write(func(c *gin.Context) {
+		err := responseutils.NewResponseError(responseutils.ErrCodeValidation, message, http.StatusBadRequest)
+		err.Details = d
+		responseutils.ErrorResponse(c, err)
+	})
+
+	var envelope struct {
+		Success *bool `json:"success"`
+		Error   struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("envelope is not valid JSON: %v: %s", err, body)
+	}
+	if envelope.Success == nil || *envelope.Success {
+		return fmt.Errorf("error envelope without success=false: %s", body)
+	}
+	if envelope.Error.Code != responseutils.ErrCodeValidation {
+		return fmt.Errorf("code changed to %q", envelope.Error.Code)
+	}
+	if utf8.ValidString(message) && envelope.Error.Message != message {
+		return fmt.Errorf("message changed to %q", envelope.Error.Message)
+	}
+	return nil
+}
+
+// envelopeDecoder decodes untrusted bytes into the envelope DTOs as a client
+// does, and checks that an envelope which decodes is written back by the
+// writers with the same data, message, error code and details
+func envelopeDecoder(data []byte) error {
+	var probe struct {
+		Success *bool `json:"success"`
+	}
+	if json.Unmarshal(data, &probe) != nil || probe.Success == nil {
+		return nil
+	}
+
+	if *probe.Success {
+		var env responseutils.SuccessResponseDTO
+		if decode(data, &env) != nil {
+			return nil
+		}
+		body := write(func(c *gin.Context) { responseutils.SuccessResponse(c, http.StatusOK, env.Data, env.Message) })
+		var again responseutils.SuccessResponseDTO
+		if err := decode(body, &again); err != nil {
+			return fmt.Errorf("written envelope does not decode: %v: %s", err, body)
+		}
+		if !again.Success || again.Message != env.Message || !reflect.DeepEqual(again.Data, env.Data) {
+			return fmt.Errorf("envelope changed on writing: %s", body)
+		}
+		return nil
+	}
+
+	var env responseutils.ErrorResponseDTO
+	if decode(data, &env) != nil || env.Error.Code == "" {
+		return nil
+	}
+	details, _ := env.Error.Details.(map[string]interface{})
+	body := write(func(c *gin.Context) {
+		err := responseutils.NewResponseError(env.Error.Code, env.Error.Message, http.StatusBadRequest)
+		err.Details = details
+		responseutils.ErrorResponse(c, err)
+	})
+	var again responseutils.ErrorResponseDTO
+	if err := decode(body, &again); err != nil {
+		return fmt.Errorf("written envelope does not decode: %v: %s", err, body)
+	}
+	againDetails, _ := again.Error.Details.(map[string]interface{})
+	if again.Success || again.Error.Code != env.Error.Code || again.Error.Message != env.Error.Message ||
+		len(details) > 0 && !reflect.DeepEqual(againDetails, details) {
+		return fmt.Errorf("error envelope changed on writing: %s", body)
+	}
+	return nil
+}
+
+// decode decodes JSON as clients should, keeping numbers exact
+func decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// write runs a writer against a fresh context and returns the body
+func write(fn func(c *gin.Context)) []byte {
+	gin.SetMode(gin.ReleaseMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/fuzz", nil)
+	fn(c)
+	return w.Body.Bytes()
+}
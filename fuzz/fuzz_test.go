@@ -0,0 +1,17 @@
+package fuzz
+
+import "testing"
+
+func FuzzSearchCursor(f *testing.F) { Lookup("SearchCursor").Fuzz(f) }
+
+func FuzzSyncToken(f *testing.F) { Lookup("SyncToken").Fuzz(f) }
+
+func FuzzErrorReference(f *testing.F) { Lookup("ErrorReference").Fuzz(f) }
+
+func FuzzDetails(f *testing.F) { Lookup("Details").Fuzz(f) }
+
+func FuzzNegotiateMediaType(f *testing.F) { Lookup("NegotiateMediaType").Fuzz(f) }
+
+func FuzzEnvelopeDecoder(f *testing.F) { Lookup("EnvelopeDecoder").Fuzz(f) }
+
+func FuzzErrorWriter(f *testing.F) { Lookup("ErrorWriter").Fuzz(f) }
@@ -0,0 +1,76 @@
+package fuzz
+
+import (
+	"time"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+func cursorSeeds() [][]byte {
+	return [][]byte{
+		[]byte(""),
+		[]byte(responseutils.EncodeSearchCursor(responseutils.SearchCursor{SearchAfter: []interface{}{1718000000000, "order-42"}})),
+		[]byte(responseutils.EncodeSearchCursor(responseutils.SearchCursor{PIT: "46ToAwMDaWR5", SearchAfter: []interface{}{9007199254740993}})),
+		[]byte(responseutils.EncodeSyncToken("1042")),
+		[]byte("eyJwIjoiMTA0MiJ9.c2lnbmF0dXJl"),
+		[]byte("not a cursor"),
+	}
+}
+
+// issuedReference is the one valid reference in the corpus. It is encoded
+// once, as every encoding uses a fresh nonce.
+var (
+	issuedReference = responseutils.ErrorReference{
+		RequestID:   "req-42",
+		Fingerprint: responseutils.ErrorFingerprint("GET /orders/:id", responseutils.ErrCodeInternalServer, "boom"),
+		Timestamp:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Status:      500,
+		Code:        responseutils.ErrCodeInternalServer,
+	}
+	encodedReference = mustEncodeReference(issuedReference)
+)
+
+func referenceSeeds() [][]byte {
+	return [][]byte{[]byte(encodedReference), []byte("ref1_"), []byte("ref1_AAAA")}
+}
+
+func mustEncodeReference(ref responseutils.ErrorReference) string {
+	encoded, err := responseutils.EncodeErrorReference(referenceSecret, ref)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+func detailsSeeds() [][]byte {
+	return [][]byte{
+		[]byte(`{}`),
+		[]byte(`null`),
+		[]byte(`{"field":"email","reason":"must be a valid email address"}`),
+		[]byte(`{"fields":[{"field":"name","message":"is required"}],"retry_after":30}`),
+		[]byte(`{"z":1,"a":{"nested":[true,null,1.5e300]},"é":"😀"}`),
+	}
+}
+
+func acceptSeeds() [][]byte {
+	return [][]byte{
+		[]byte(""),
+		[]byte("*/*"),
+		[]byte("application/json"),
+		[]byte("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"),
+		[]byte("application/json;q=0, */*;q=0.1"),
+		[]byte("text/*;q=0.5, application/problem+json;q=1.0;charset=utf-8"),
+		[]byte(";;,,q=,*/"),
+	}
+}
+
+func envelopeSeeds() [][]byte {
+	return [][]byte{
+		[]byte(`{"success":true}`),
+		[]byte(`{"success":true,"data":{"id":9007199254740993,"name":"Ada"},"message":"ok"}`),
+		[]byte(`{"success":true,"data":[1,2.50,"x",null,{"nested":[true]}]}`),
+		[]byte(`{"success":false,"error":{"code":"NOT_FOUND","message":"User not found"}}`),
+		[]byte(`{"success":false,"error":{"code":"VALIDATION_ERROR","message":"Invalid","details":{"fields":[{"field":"email","message":"is required"}]}}}`),
+		[]byte(`{"success":false,"error":"plain string"}`),
+	}
+}
@@ -0,0 +1,196 @@
+package responseutils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// CapturedResponse is a response written by a handler without a network
+// connection, ready to be reshaped for a serverless platform
+type CapturedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// CaptureResponse serves req with h, typically a *gin.Engine, and returns the response it wrote
+func CaptureResponse(h http.Handler, req *http.Request) *CapturedResponse {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return &CapturedResponse{
+		StatusCode: rec.Code,
+		Header:     rec.Header(),
+		Body:       rec.Body.Bytes(),
+	}
+}
+
+// APIGatewayProxyResponse is the AWS API Gateway Lambda proxy integration response format
+type APIGatewayProxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// APIGatewayProxy reshapes the response to the Lambda proxy format. Binary
+// bodies such as images are base64 encoded.
+func (r *CapturedResponse) APIGatewayProxy() APIGatewayProxyResponse {
+	out := APIGatewayProxyResponse{
+		StatusCode:        r.StatusCode,
+		Headers:           make(map[string]string, len(r.Header)),
+		MultiValueHeaders: make(map[string][]string, len(r.Header)),
+	}
+
+	for k, v := range r.Header {
+		if len(v) == 0 {
+			continue
+		}
+		out.Headers[k] = v[0]
+		out.MultiValueHeaders[k] = append([]string(nil), v...)
+	}
+
+	if IsTextContentType(r.Header.Get("Content-Type")) {
+		out.Body = string(r.Body)
+	} else if len(r.Body) > 0 {
+		out.Body = base64.StdEncoding.EncodeToString(r.Body)
+		out.IsBase64Encoded = true
+	}
+
+	return out
+}
+
+// CloudFunctionsCallable reshapes a JSON envelope to the Google Cloud
+// Functions callable protocol: {"result": data} on success and
+// {"error": {"status", "message", "details"}} on failure. Other responses
+// are returned unchanged.
+func (r *CapturedResponse) CloudFunctionsCallable() *CapturedResponse {
+	var env struct {
+		Success *bool           `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   *struct {
+			Code    string          `json:"code"`
+			Message string          `json:"message"`
+			Details json.RawMessage `json:"details"`
+		} `json:"error"`
+	}
+	if !isJSONContentType(r.Header.Get("Content-Type")) || json.Unmarshal(r.Body, &env) != nil || env.Success == nil {
+		return r
+	}
+
+	var payload interface{}
+	if *env.Success {
+		result := env.Data
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		payload = map[string]interface{}{"result": result}
+	} else {
+		callableErr := map[string]interface{}{"status": CallableStatus(r.StatusCode)}
+		if env.Error != nil {
+			callableErr["message"] = env.Error.Message
+			details := map[string]interface{}{"code": env.Error.Code}
+			if env.Error.Details != nil {
+				details["details"] = env.Error.Details
+			}
+			callableErr["details"] = details
+		}
+		payload = map[string]interface{}{"error": callableErr}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return r
+	}
+
+	header := r.Header.Clone()
+	header.Set("Content-Type", jsonContentType)
+	header.Del("Content-Length")
+	return &CapturedResponse{StatusCode: r.StatusCode, Header: header, Body: body}
+}
+
+// WriteTo writes the response to w, e.g. from a Cloud Functions HTTP function
+func (r *CapturedResponse) WriteTo(w http.ResponseWriter) {
+	for k, v := range r.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.StatusCode)
+	w.Write(r.Body)
+}
+
+// CloudFunctionsHandler adapts h to a Cloud Functions HTTP function
+// answering in the callable protocol format
+func CloudFunctionsHandler(h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		CaptureResponse(h, req).CloudFunctionsCallable().WriteTo(w)
+	}
+}
+
+// CallableStatus maps an HTTP status code to the canonical status name used
+// by the Cloud Functions callable protocol
+func CallableStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "ALREADY_EXISTS"
+	case http.StatusPreconditionFailed:
+		return "FAILED_PRECONDITION"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case 499:
+		return "CANCELLED"
+	case http.StatusNotImplemented:
+		return "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	case http.StatusInternalServerError:
+		return "INTERNAL"
+	}
+	return "UNKNOWN"
+}
+
+// IsTextContentType reports whether a body of the given content type can be
+// carried as a string by serverless platforms rather than base64 encoded
+func IsTextContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		isJSONContentType(mediaType),
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/xml",
+		mediaType == "application/javascript",
+		mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// isJSONContentType reports whether contentType is application/json or a +json type
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,111 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Region restriction reasons
+const (
+	RestrictionEmbargo    = "embargo"
+	RestrictionLicensing  = "licensing"
+	RestrictionRegulatory = "regulatory"
+)
+
+// RegionRestricted creates a 451 error for requests from a country the
+// service cannot serve. Add the reason with WithDetails("reason", ...).
+func RegionRestricted(country string) *ResponseError {
+	return NewResponseError(
+		ErrCodeRegionRestricted,
+		fmt.Sprintf("This service is not available in region '%s'", country),
+		http.StatusUnavailableForLegalReasons,
+	).WithDetails("country", country)
+}
+
+// GeoResolver resolves the ISO 3166-1 alpha-2 country code of a request
+type GeoResolver interface {
+	Country(c *gin.Context) (string, error)
+}
+
+// GeoResolverFunc adapts a function to the GeoResolver interface
+type GeoResolverFunc func(c *gin.Context) (string, error)
+
+// Country implements GeoResolver
+func (f GeoResolverFunc) Country(c *gin.Context) (string, error) {
+	return f(c)
+}
+
+// HeaderGeoResolver resolves the country from a header set by a CDN or load
+// balancer, e.g. CF-IPCountry or CloudFront-Viewer-Country
+func HeaderGeoResolver(header string) GeoResolver {
+	return GeoResolverFunc(func(c *gin.Context) (string, error) {
+		return c.GetHeader(header), nil
+	})
+}
+
+// GeoRestrictionOptions configure GeoRestrict
+type GeoRestrictionOptions struct {
+	// Blocked maps restricted country codes to the restriction reason
+	Blocked map[string]string
+	// Allowed, when not empty, restricts every country not listed
+	Allowed []string
+	// AllowedReason is the reason sent for countries outside Allowed
+	AllowedReason string
+	// StatusCode is 451 (default) or 403
+	StatusCode int
+	// BlockUnknown restricts requests whose country cannot be resolved
+	BlockUnknown bool
+}
+
+// GeoRestrict returns middleware short-circuiting requests from restricted
+// regions with the RegionRestricted error, including the restriction reason
+func GeoRestrict(resolver GeoResolver, opts GeoRestrictionOptions) gin.HandlerFunc {
+	blocked := make(map[string]string, len(opts.Blocked))
+	for country, reason := range opts.Blocked {
+		blocked[strings.ToUpper(country)] = reason
+	}
+	allowed := make(map[string]bool, len(opts.Allowed))
+	for _, country := range opts.Allowed {
+		allowed[strings.ToUpper(country)] = true
+	}
+
+	return func(c *gin.Context) {
+		country, err := resolver.Country(c)
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if err != nil || country == "" || country == "XX" {
+			if err != nil {
+				debugPrintf("geo resolver failed on %s: %v", c.FullPath(), err)
+			}
+			if opts.BlockUnknown {
+				restrict(c, opts, RegionRestricted("unknown"), RestrictionRegulatory)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if reason, ok := blocked[country]; ok {
+			restrict(c, opts, RegionRestricted(country), reason)
+			return
+		}
+		if len(allowed) > 0 && !allowed[country] {
+			restrict(c, opts, RegionRestricted(country), opts.AllowedReason)
+			return
+		}
+		c.Next()
+	}
+}
+
+func restrict(c *gin.Context, opts GeoRestrictionOptions, err *ResponseError, reason string) {
+	if opts.StatusCode == http.StatusForbidden {
+		err.StatusCode = http.StatusForbidden
+	}
+	if reason != "" {
+		err.WithDetails("reason", reason)
+	}
+	ErrorResponse(c, err)
+	c.Abort()
+}
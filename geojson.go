@@ -0,0 +1,260 @@
+package responseutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaTypeGeoJSON is the RFC 7946 GeoJSON media type
+const MediaTypeGeoJSON = "application/geo+json"
+
+// BBoxParam is the conventional query parameter carrying a bounding box
+const BBoxParam = "bbox"
+
+// Geometry types
+const (
+	GeometryPoint           = "Point"
+	GeometryMultiPoint      = "MultiPoint"
+	GeometryLineString      = "LineString"
+	GeometryMultiLineString = "MultiLineString"
+	GeometryPolygon         = "Polygon"
+	GeometryMultiPolygon    = "MultiPolygon"
+	GeometryCollection      = "GeometryCollection"
+)
+
+const (
+	featureType                = "Feature"
+	featureCollectionType      = "FeatureCollection"
+	maxGeometryCollectionDepth = 8
+)
+
+// BBox is a bounding box: west, south, east, north, optionally with the
+// minimum and maximum altitude after south and north
+type BBox []float64
+
+// FeatureCollection is a GeoJSON feature collection. Pagination is a foreign
+// member describing the page of features returned for a bbox query.
+// @Description GeoJSON feature collection structure
+type FeatureCollection struct {
+	Type       string         `json:"type" example:"FeatureCollection"`
+	Features   []Feature      `json:"features"`
+	BBox       BBox           `json:"bbox,omitempty"`
+	Pagination *GeoPagination `json:"pagination,omitempty"`
+}
+
+// Feature is a GeoJSON feature
+// @Description GeoJSON feature structure
+type Feature struct {
+	Type       string      `json:"type" example:"Feature"`
+	ID         interface{} `json:"id,omitempty"`
+	Geometry   *Geometry   `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates are kept encoded; their
+// nesting depends on Type.
+// @Description GeoJSON geometry structure
+type Geometry struct {
+	Type        string          `json:"type" example:"Point"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty" swaggertype:"array,number"`
+	Geometries  []Geometry      `json:"geometries,omitempty"`
+}
+
+// GeoPagination describes a page of features within a bounding box
+// @Description GeoJSON pagination structure
+type GeoPagination struct {
+	BBox       BBox   `json:"bbox,omitempty"`
+	Limit      int    `json:"limit" example:"500"`
+	Returned   int    `json:"returned" example:"500"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJsYXN0X2lkIjo1MDB9"`
+}
+
+// NewPointGeometry returns a Point geometry at lon, lat
+func NewPointGeometry(lon, lat float64) *Geometry {
+	coords, _ := json.Marshal([]float64{lon, lat})
+	return &Geometry{Type: GeometryPoint, Coordinates: coords}
+}
+
+// GeoJSONResponse sends a 200 OK feature collection as application/geo+json.
+// The collection is the document itself rather than being wrapped in the
+// success envelope, as GeoJSON clients expect.
+func GeoJSONResponse(c *gin.Context, fc FeatureCollection) {
+	fc.Type = featureCollectionType
+	if fc.Features == nil {
+		fc.Features = []Feature{}
+	}
+	for i := range fc.Features {
+		fc.Features[i].Type = featureType
+	}
+	if fc.Pagination != nil {
+		fc.Pagination.Returned = len(fc.Features)
+	}
+	writeTyped(c, http.StatusOK, MediaTypeGeoJSON, fc)
+}
+
+// ParseBBox parses the bbox query parameter as "west,south,east,north". An
+// absent parameter returns a nil box. West may exceed east for boxes
+// crossing the antimeridian.
+func ParseBBox(c *gin.Context) (BBox, *ResponseError) {
+	raw := c.Query(BBoxParam)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 && len(parts) != 6 {
+		return nil, InvalidBBox("expected 4 or 6 comma-separated numbers")
+	}
+	box := make(BBox, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, InvalidBBox(fmt.Sprintf("'%s' is not a number", p))
+		}
+		box[i] = v
+	}
+
+	dims := len(box) / 2
+	west, south, east, north := box[0], box[1], box[dims], box[dims+1]
+	switch {
+	case west < -180 || west > 180 || east < -180 || east > 180:
+		return nil, InvalidBBox("longitude must be between -180 and 180")
+	case south < -90 || south > 90 || north < -90 || north > 90:
+		return nil, InvalidBBox("latitude must be between -90 and 90")
+	case south > north:
+		return nil, InvalidBBox("south must not be greater than north")
+	}
+	return box, nil
+}
+
+// ValidateGeometry checks a geometry received from a client: a known type,
+// coordinates nested as the type requires, positions within longitude and
+// latitude bounds, line strings with at least two positions and polygon
+// rings that are closed and have at least four positions
+func ValidateGeometry(g *Geometry) *ResponseError {
+	if g == nil {
+		return InvalidGeometry("geometry is required")
+	}
+	return validateGeometry(g, 0)
+}
+
+func validateGeometry(g *Geometry, depth int) *ResponseError {
+	if g.Type == GeometryCollection {
+		if depth >= maxGeometryCollectionDepth {
+			return InvalidGeometry("geometry collections are nested too deeply")
+		}
+		for i := range g.Geometries {
+			if err := validateGeometry(&g.Geometries[i], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	switch g.Type {
+	case GeometryPoint:
+		var p []float64
+		if err = json.Unmarshal(g.Coordinates, &p); err == nil {
+			err = validatePosition(p)
+		}
+	case GeometryMultiPoint:
+		var ps [][]float64
+		if err = json.Unmarshal(g.Coordinates, &ps); err == nil {
+			err = validatePositions(ps, 1)
+		}
+	case GeometryLineString:
+		var ps [][]float64
+		if err = json.Unmarshal(g.Coordinates, &ps); err == nil {
+			err = validatePositions(ps, 2)
+		}
+	case GeometryMultiLineString:
+		var lines [][][]float64
+		if err = json.Unmarshal(g.Coordinates, &lines); err == nil {
+			for _, ps := range lines {
+				if err = validatePositions(ps, 2); err != nil {
+					break
+				}
+			}
+		}
+	case GeometryPolygon:
+		var rings [][][]float64
+		if err = json.Unmarshal(g.Coordinates, &rings); err == nil {
+			err = validatePolygon(rings)
+		}
+	case GeometryMultiPolygon:
+		var polygons [][][][]float64
+		if err = json.Unmarshal(g.Coordinates, &polygons); err == nil {
+			for _, rings := range polygons {
+				if err = validatePolygon(rings); err != nil {
+					break
+				}
+			}
+		}
+	default:
+		return InvalidGeometry(fmt.Sprintf("unknown geometry type '%s'", g.Type))
+	}
+
+	if err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok || len(g.Coordinates) == 0 {
+			err = fmt.Errorf("coordinates are not nested as a %s requires", g.Type)
+		}
+		return InvalidGeometry(err.Error()).WithDetails("type", g.Type)
+	}
+	return nil
+}
+
+func validatePolygon(rings [][][]float64) error {
+	if len(rings) == 0 {
+		return fmt.Errorf("a polygon needs at least one ring")
+	}
+	for _, ring := range rings {
+		if err := validatePositions(ring, 4); err != nil {
+			return err
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("polygon rings must be closed")
+		}
+	}
+	return nil
+}
+
+func validatePositions(ps [][]float64, minPositions int) error {
+	if len(ps) < minPositions {
+		return fmt.Errorf("expected at least %d positions, got %d", minPositions, len(ps))
+	}
+	for _, p := range ps {
+		if err := validatePosition(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePosition(p []float64) error {
+	switch {
+	case len(p) < 2 || len(p) > 3:
+		return fmt.Errorf("a position needs a longitude, a latitude and an optional altitude")
+	case p[0] < -180 || p[0] > 180:
+		return fmt.Errorf("longitude %v is out of range", p[0])
+	case p[1] < -90 || p[1] > 90:
+		return fmt.Errorf("latitude %v is out of range", p[1])
+	}
+	return nil
+}
+
+func InvalidGeometry(reason string) *ResponseError {
+	return NewResponseError(ErrCodeInvalidGeometry, "Invalid geometry: "+reason, http.StatusBadRequest)
+}
+
+func InvalidBBox(reason string) *ResponseError {
+	return NewResponseError(ErrCodeInvalidBBox, "Invalid bounding box: "+reason, http.StatusBadRequest).
+		WithDetails("param", BBoxParam)
+}
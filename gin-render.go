@@ -0,0 +1,58 @@
+package responseutils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// JSON is a gin render.Render writing the success envelope, so c.JSON call
+// sites can be migrated one at a time by swapping the render type:
+//
+//	c.Render(http.StatusOK, responseutils.JSON{Data: user}) // was c.JSON(http.StatusOK, user)
+//
+// gin renders only get the response writer, so the envelope is encoded with
+// the current encoder but response hooks, defaults, meta and links are not
+// applied; move call sites on to OKResponse and friends to get them.
+type JSON struct {
+	Data    interface{}
+	Message string
+}
+
+var _ render.Render = JSON{}
+
+// Render implements render.Render
+func (r JSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	data, err := CurrentEncoder().Marshal(Response{Success: true, Data: r.Data, Message: r.Message})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteContentType implements render.Render
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	if header := w.Header(); len(header["Content-Type"]) == 0 {
+		header["Content-Type"] = []string{jsonContentType}
+	}
+}
+
+// AbortWithError is the envelope counterpart of gin's c.AbortWithError: it
+// sends err with ErrorResponse, stops the remaining handlers and records err
+// in c.Errors for logging middleware. The status code comes from the error.
+// Pooled errors are recorded as a copy, as they are released once written.
+func AbortWithError(c *gin.Context, err error) *gin.Error {
+	recorded := err
+	var appErr *ResponseError
+	if errors.As(err, &appErr) && appErr.Pooled() {
+		recorded = appErr.Clone()
+	}
+
+	ErrorResponse(c, err)
+	c.Abort()
+	return c.Error(recorded)
+}
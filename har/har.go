@@ -0,0 +1,186 @@
+// Package har exports recorded exchanges in the HTTP Archive (HAR) 1.2
+// format, which browsers' developer tools and most HTTP debugging tools can
+// import, for sharing production recordings with client teams.
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// Version is the HAR format version produced
+const Version = "1.2"
+
+// HAR is the root of an HTTP Archive
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the archived entries
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that created the archive
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request and its response
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+}
+
+// Request is an archived request
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is an archived response
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header, cookie or query parameter
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is an archived request body
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is an archived response body
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings break down the entry's time; only the server wait is known
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// FromExchanges builds an archive from recorded exchanges
+func FromExchanges(exchanges []*responseutils.RecordedExchange) *HAR {
+	h := &HAR{Log: Log{
+		Version: Version,
+		Creator: Creator{Name: "response-utils", Version: Version},
+		Entries: make([]Entry, 0, len(exchanges)),
+	}}
+
+	for _, x := range exchanges {
+		h.Log.Entries = append(h.Log.Entries, entry(x))
+	}
+	return h
+}
+
+// Write writes the exchanges to w as a HAR document
+func Write(w io.Writer, exchanges []*responseutils.RecordedExchange) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(FromExchanges(exchanges))
+}
+
+func entry(x *responseutils.RecordedExchange) Entry {
+	ms := float64(x.Duration) / float64(time.Millisecond)
+
+	req := Request{
+		Method:      x.Method,
+		URL:         x.URL,
+		HTTPVersion: x.Proto,
+		Cookies:     []NameValue{},
+		Headers:     nameValues(x.RequestHeader),
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(x.RequestBody),
+	}
+	if u, err := url.Parse(x.URL); err == nil {
+		req.QueryString = nameValues(u.Query())
+	}
+	if len(x.RequestBody) > 0 {
+		req.PostData = &PostData{MimeType: x.RequestHeader.Get("Content-Type"), Text: string(x.RequestBody)}
+	}
+
+	contentType := x.ResponseHeader.Get("Content-Type")
+	content := Content{Size: len(x.ResponseBody), MimeType: contentType}
+	if responseutils.IsTextContentType(contentType) {
+		content.Text = string(x.ResponseBody)
+	} else if len(x.ResponseBody) > 0 {
+		content.Text = base64.StdEncoding.EncodeToString(x.ResponseBody)
+		content.Encoding = "base64"
+	}
+
+	return Entry{
+		StartedDateTime: x.StartedAt.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request:         req,
+		Response: Response{
+			Status:      x.StatusCode,
+			StatusText:  http.StatusText(x.StatusCode),
+			HTTPVersion: x.Proto,
+			Cookies:     []NameValue{},
+			Headers:     nameValues(x.ResponseHeader),
+			Content:     content,
+			RedirectURL: x.ResponseHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(x.ResponseBody),
+		},
+		Timings: Timings{Wait: ms},
+	}
+}
+
+// nameValues flattens headers or query values in sorted name order
+func nameValues(values map[string][]string) []NameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := []NameValue{}
+	for _, name := range names {
+		for _, v := range values[name] {
+			out = append(out, NameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IDKind is the identifier format a service uses for its resources
+type IDKind int32
+
+const (
+	IDKindUUID IDKind = iota
+	IDKindULID
+	IDKindSnowflake
+)
+
+var idKind atomic.Int32
+
+// SetIDKind sets the identifier format ParseIDParam accepts; the default is IDKindUUID
+func SetIDKind(kind IDKind) {
+	idKind.Store(int32(kind))
+}
+
+// CurrentIDKind returns the identifier format ParseIDParam accepts
+func CurrentIDKind() IDKind {
+	return IDKind(idKind.Load())
+}
+
+// String returns the name of the identifier format
+func (k IDKind) String() string {
+	switch k {
+	case IDKindULID:
+		return "ulid"
+	case IDKindSnowflake:
+		return "snowflake"
+	}
+	return "uuid"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k IDKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func InvalidULID(field string) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidULID,
+		fmt.Sprintf("Invalid ULID format for field '%s'", field),
+		http.StatusBadRequest,
+	)
+}
+
+func InvalidID(field string) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidID,
+		fmt.Sprintf("Invalid ID format for field '%s'", field),
+		http.StatusBadRequest,
+	)
+}
+
+// ParseIDParam reads the named path or query parameter and validates it in
+// the service's identifier format set with SetIDKind, returning it in
+// canonical form: lowercase UUIDs, uppercase ULIDs and decimal snowflakes
+func ParseIDParam(c *gin.Context, name string) (string, *ResponseError) {
+	switch CurrentIDKind() {
+	case IDKindULID:
+		return ParseULIDParam(c, name)
+	case IDKindSnowflake:
+		id, err := ParseSnowflakeParam(c, name)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(id, 10), nil
+	}
+
+	id, err := ParseUUIDParam(c, name)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// ParseUUIDParam reads the named path parameter, or the query parameter of
+// that name when the route has none, and parses it as a UUID. Missing or
+// malformed values return the InvalidUUID error.
+func ParseUUIDParam(c *gin.Context, name string) (uuid.UUID, *ResponseError) {
+	raw := paramValue(c, name)
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, InvalidUUID(name).WithDetails("value", raw)
+	}
+	return id, nil
+}
+
+// ParseULIDParam reads the named path or query parameter as a ULID,
+// returning it uppercased or the InvalidULID error
+func ParseULIDParam(c *gin.Context, name string) (string, *ResponseError) {
+	raw := paramValue(c, name)
+	if !ValidULID(raw) {
+		return "", InvalidULID(name).WithDetails("value", raw)
+	}
+	return strings.ToUpper(raw), nil
+}
+
+// ParseSnowflakeParam reads the named path or query parameter as a numeric
+// snowflake ID, returning the InvalidID error unless it is a positive 64-bit integer
+func ParseSnowflakeParam(c *gin.Context, name string) (uint64, *ResponseError) {
+	raw := paramValue(c, name)
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return 0, InvalidID(name).WithDetails("value", raw)
+	}
+	return id, nil
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ValidULID reports whether s is a 26-character Crockford base32 ULID
+// within the 128-bit range
+func ValidULID(s string) bool {
+	if len(s) != 26 {
+		return false
+	}
+	s = strings.ToUpper(s)
+	// The first character encodes the top 3 bits of the 48-bit timestamp
+	if s[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(crockford, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// paramValue returns the named path parameter, or the query parameter of
+// that name when the route has none
+func paramValue(c *gin.Context, name string) string {
+	if raw, ok := c.Params.Get(name); ok {
+		return raw
+	}
+	return c.Query(name)
+}
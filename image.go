@@ -0,0 +1,134 @@
+package responseutils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoding
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Image media types
+const (
+	MediaTypeAVIF = "image/avif"
+	MediaTypeWebP = "image/webp"
+	MediaTypeJPEG = "image/jpeg"
+	MediaTypePNG  = "image/png"
+)
+
+// ImageEncoder encodes an image in a single format. Quality is in the range
+// 1-100 and may be ignored by lossless formats.
+type ImageEncoder func(w io.Writer, img image.Image, quality int) error
+
+// ImageOptions configures ImageResponse
+type ImageOptions struct {
+	// Formats lists the offered media types in order of preference.
+	// Defaults to AVIF, WebP, JPEG then PNG, limited to registered encoders.
+	Formats []string
+	// Quality is passed to lossy encoders; defaults to 85
+	Quality int
+	// MaxAge sets Cache-Control max-age; zero disables caching
+	MaxAge time.Duration
+	// Immutable marks the response as never changing at this URL
+	Immutable bool
+}
+
+var (
+	imageEncodersMu sync.RWMutex
+	imageEncoders   = map[string]ImageEncoder{
+		MediaTypeJPEG: func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		MediaTypePNG: func(w io.Writer, img image.Image, _ int) error {
+			return png.Encode(w, img)
+		},
+	}
+	defaultImageFormats = []string{MediaTypeAVIF, MediaTypeWebP, MediaTypeJPEG, MediaTypePNG}
+)
+
+// RegisterImageEncoder registers an encoder for a media type, e.g. a WebP or
+// AVIF encoder from a third-party library. JPEG and PNG are built in.
+func RegisterImageEncoder(mediaType string, enc ImageEncoder) {
+	imageEncodersMu.Lock()
+	defer imageEncodersMu.Unlock()
+
+	imageEncoders[mediaType] = enc
+}
+
+// InvalidImage creates a 400 error for image input that could not be decoded
+func InvalidImage(err error) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidImage,
+		"Image could not be decoded",
+		http.StatusBadRequest,
+	).WithDetails("error", err.Error())
+}
+
+// DecodeImage decodes an image, returning InvalidImage on failure
+func DecodeImage(r io.Reader) (image.Image, string, *ResponseError) {
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", InvalidImage(err)
+	}
+	return img, format, nil
+}
+
+// ImageResponse encodes img in the format best matching the Accept header
+// and writes it with cache headers. Requests accepting none of the offered
+// formats are handled by the negotiation policy.
+func ImageResponse(c *gin.Context, img image.Image, opts ImageOptions) {
+	imageEncodersMu.RLock()
+	formats := opts.Formats
+	if len(formats) == 0 {
+		formats = defaultImageFormats
+	}
+	offered := make([]string, 0, len(formats))
+	for _, f := range formats {
+		if _, ok := imageEncoders[f]; ok {
+			offered = append(offered, f)
+		}
+	}
+	imageEncodersMu.RUnlock()
+
+	addVary(c.Writer.Header(), "Accept")
+
+	mediaType, ok := negotiate(c, offered...)
+	if !ok {
+		ErrorResponse(c, NotAcceptable(offered))
+		return
+	}
+
+	imageEncodersMu.RLock()
+	enc := imageEncoders[mediaType]
+	imageEncodersMu.RUnlock()
+
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+
+	var buf bytes.Buffer
+	if err := enc(&buf, img, quality); err != nil {
+		ErrorResponse(c, InternalServerError(fmt.Sprintf("Failed to encode image as %s", mediaType)).
+			WithDetails("error", err.Error()))
+		return
+	}
+
+	if opts.MaxAge > 0 {
+		cacheControl := "public, max-age=" + strconv.Itoa(int(opts.MaxAge.Seconds()))
+		if opts.Immutable {
+			cacheControl += ", immutable"
+		}
+		c.Header("Cache-Control", cacheControl)
+	}
+
+	writeBody(c, http.StatusOK, mediaType, buf.Bytes())
+}
@@ -0,0 +1,221 @@
+// Package invariants checks the guarantees every response-utils envelope
+// keeps, so services can assert them against their own handlers in tests:
+//
+//	func TestOrderInvariants(t *testing.T) {
+//		invariants.Serve(t, router, httptest.NewRequest("GET", "/orders/missing", nil))
+//		invariants.CheckErrorCodes(t)
+//	}
+//
+// Error envelopes and problem details always carry a code and a message,
+// success envelopes never carry an error, the success flag agrees with the
+// status code and error status codes match the code registry.
+package invariants
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Rules reported in violations
+const (
+	// RuleErrorFields: error envelopes and problems have a code and message
+	RuleErrorFields = "error-fields"
+	// RuleSuccessWithoutError: success envelopes have no error
+	RuleSuccessWithoutError = "success-without-error"
+	// RuleSuccessStatus: success envelopes are sent with status codes below
+	// 400 and error envelopes with 400 and above
+	RuleSuccessStatus = "success-status"
+	// RuleRegisteredStatus: an error code is sent with its registered status
+	RuleRegisteredStatus = "registered-status"
+	// RuleRegisteredCode: error codes are registered
+	RuleRegisteredCode = "registered-code"
+)
+
+// Violation is a broken invariant
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// String formats the violation for test failures
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Options configure Check
+type Options struct {
+	// Skip lists rules not to check, e.g. RuleRegisteredCode for services
+	// that do not register their codes
+	Skip []string
+}
+
+// Check returns the invariants a response breaks. Bodies that are not JSON
+// envelopes or problem details, e.g. CSV exports or GeoJSON, are not checked.
+func Check(r *responseutils.CapturedResponse, opts Options) []Violation {
+	skip := map[string]bool{}
+	for _, rule := range opts.Skip {
+		skip[rule] = true
+	}
+	var violations []Violation
+	report := func(rule, format string, args ...interface{}) {
+		if !skip[rule] {
+			violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "json") || len(r.Body) == 0 {
+		return nil
+	}
+	var body map[string]json.RawMessage
+	if json.Unmarshal(r.Body, &body) != nil {
+		return nil
+	}
+
+	if strings.HasPrefix(contentType, "application/problem+json") {
+		var problem responseutils.ProblemDetails
+		if err := json.Unmarshal(r.Body, &problem); err != nil {
+			report(RuleErrorFields, "problem details do not decode: %v", err)
+			return violations
+		}
+		if problem.Code == "" || problem.Detail == "" {
+			report(RuleErrorFields, "problem %q is missing its code or detail", problem.Code)
+		}
+		if problem.Status != r.StatusCode {
+			report(RuleSuccessStatus, "problem status %d sent with status code %d", problem.Status, r.StatusCode)
+		}
+		checkRegistry(problem.Code, r.StatusCode, report)
+		return violations
+	}
+
+	var success bool
+	if json.Unmarshal(body["success"], &success) != nil {
+		// not an envelope
+		return nil
+	}
+	errRaw, hasError := body["error"]
+	hasError = hasError && string(errRaw) != "null"
+
+	if success {
+		if hasError {
+			report(RuleSuccessWithoutError, "success envelope carries an error: %s", errRaw)
+		}
+		if r.StatusCode >= http.StatusBadRequest {
+			report(RuleSuccessStatus, "success envelope sent with status code %d", r.StatusCode)
+		}
+		return violations
+	}
+
+	if r.StatusCode < http.StatusBadRequest {
+		report(RuleSuccessStatus, "error envelope sent with status code %d", r.StatusCode)
+	}
+	var e struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if !hasError || json.Unmarshal(errRaw, &e) != nil {
+		report(RuleErrorFields, "error envelope without an error object")
+		return violations
+	}
+	if e.Code == "" || e.Message == "" {
+		report(RuleErrorFields, "error %q is missing its code or message", e.Code)
+	}
+	checkRegistry(e.Code, r.StatusCode, report)
+	return violations
+}
+
+func checkRegistry(code string, statusCode int, report func(rule, format string, args ...interface{})) {
+	if code == "" {
+		return
+	}
+	info, ok := responseutils.LookupErrorCode(code)
+	if !ok {
+		report(RuleRegisteredCode, "error code %s is not registered", code)
+		return
+	}
+	want := info.StatusCode
+	if code == responseutils.ErrCodeValidation && want == http.StatusBadRequest {
+		want = responseutils.CurrentValidationPolicy().StatusCode()
+	}
+	if statusCode != want {
+		report(RuleRegisteredStatus, "error code %s sent with status code %d, registered as %d", code, statusCode, want)
+	}
+}
+
+// Assert fails the test for each invariant the response breaks
+func Assert(t testing.TB, r *responseutils.CapturedResponse, opts Options) {
+	t.Helper()
+	for _, v := range Check(r, opts) {
+		t.Errorf("%d response breaks %s", r.StatusCode, v)
+	}
+}
+
+// Serve serves req with h, asserts the response's invariants and returns it
+func Serve(t testing.TB, h http.Handler, req *http.Request) *responseutils.CapturedResponse {
+	t.Helper()
+	r := responseutils.CaptureResponse(h, req)
+	for _, v := range Check(r, Options{}) {
+		t.Errorf("%s %s: %d response breaks %s", req.Method, req.URL.Path, r.StatusCode, v)
+	}
+	return r
+}
+
+// CheckErrorCodes writes every registered error code with ErrorResponse
+// and ProblemResponse, with random messages and details, and asserts the
+// invariants of each response, covering codes a service registers itself
+func CheckErrorCodes(t testing.TB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	rng := rand.New(rand.NewSource(1))
+
+	for _, info := range responseutils.ErrorCodes() {
+		for _, write := range []func(*gin.Context, error){responseutils.ErrorResponse, responseutils.ProblemResponse} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/invariants", nil)
+			err := responseutils.NewResponseError(info.Code, randomText(rng), info.StatusCode)
+			for i := rng.Intn(4); i > 0; i-- {
+				err.WithDetails(randomText(rng), randomValue(rng))
+			}
+			write(c, err)
+
+			r := &responseutils.CapturedResponse{StatusCode: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+			for _, v := range Check(r, Options{}) {
+				t.Errorf("%s: %s", info.Code, v)
+			}
+		}
+	}
+}
+
+func randomText(rng *rand.Rand) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz _-\"\\<>&é😀"
+	runes := []rune(alphabet)
+	b := make([]rune, rng.Intn(24)+1)
+	for i := range b {
+		b[i] = runes[rng.Intn(len(runes))]
+	}
+	return string(b)
+}
+
+func randomValue(rng *rand.Rand) interface{} {
+	switch rng.Intn(5) {
+	case 0:
+		return rng.Int63()
+	case 1:
+		return rng.Float64()
+	case 2:
+		return rng.Intn(2) == 0
+	case 3:
+		return []string{randomText(rng), randomText(rng)}
+	default:
+		return randomText(rng)
+	}
+}
@@ -0,0 +1,102 @@
+package invariants
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorCodes(t *testing.T) { CheckErrorCodes(t) }
+
+func TestErrorConstructors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	constructors := map[string]*responseutils.ResponseError{
+		"AccountLocked":              responseutils.AccountLocked(now, "https://example.com/unlock"),
+		"AccountSuspended":           responseutils.AccountSuspended("abuse", "https://example.com/appeal"),
+		"EmailUnverified":            responseutils.EmailUnverified("https://example.com/verify"),
+		"PasswordExpired":            responseutils.PasswordExpired("https://example.com/password"),
+		"MFARequired":                responseutils.MFARequired("totp", "webauthn"),
+		"StepUpRequired":             responseutils.StepUpRequired(responseutils.StepUpChallenge{}),
+		"MissingAPIKey":              responseutils.MissingAPIKey("X-API-Key"),
+		"MalformedAPIKey":            responseutils.MalformedAPIKey(),
+		"InvalidAPIKey":              responseutils.InvalidAPIKey("key_1"),
+		"RevokedAPIKey":              responseutils.RevokedAPIKey("key_1", now),
+		"ExpiredAPIKey":              responseutils.ExpiredAPIKey("key_1", now),
+		"PayloadTooLarge":            responseutils.PayloadTooLarge(1 << 20),
+		"ChallengeRequired":          responseutils.ChallengeRequired(http.StatusForbidden, responseutils.BotChallenge{}),
+		"InvalidCloudEvent":          responseutils.InvalidCloudEvent("missing id"),
+		"ConcurrencyLimitExceeded":   responseutils.ConcurrencyLimitExceeded(8),
+		"InvalidConsistencyToken":    responseutils.InvalidConsistencyToken(),
+		"ShuttingDown":               responseutils.ShuttingDown(now),
+		"ParentNotFound":             responseutils.ParentNotFound("order"),
+		"UnknownEncryptionKey":       responseutils.UnknownEncryptionKey("k1"),
+		"InvalidExpansion":           responseutils.InvalidExpansion("author", "unknown relation", []string{"tags"}),
+		"RegionRestricted":           responseutils.RegionRestricted("KP"),
+		"InvalidGeometry":            responseutils.InvalidGeometry("ring not closed"),
+		"InvalidBBox":                responseutils.InvalidBBox("west after east"),
+		"InvalidULID":                responseutils.InvalidULID("id"),
+		"InvalidID":                  responseutils.InvalidID("id"),
+		"InvalidImage":               responseutils.InvalidImage(errors.New("truncated")),
+		"Overloaded":                 responseutils.Overloaded("queue full", time.Second),
+		"UnsupportedMediaType":       responseutils.UnsupportedMediaType("text/xml", []string{"application/json"}),
+		"NotAcceptable":              responseutils.NotAcceptable([]string{"application/json"}),
+		"MethodNotAllowed":           responseutils.MethodNotAllowed(http.MethodPut, []string{http.MethodGet}),
+		"InvalidAmount":              responseutils.InvalidAmount("1.234", "too many decimals"),
+		"InvalidCurrency":            responseutils.InvalidCurrency("XXX"),
+		"CurrencyMismatch":           responseutils.CurrencyMismatch("GBP", "EUR"),
+		"PasswordPolicyError":        responseutils.PasswordPolicyError([]responseutils.PolicyViolation{{}}),
+		"TaskAlreadyFinished":        responseutils.TaskAlreadyFinished("task_1", responseutils.TaskSucceeded),
+		"TaskNotCancellable":         responseutils.TaskNotCancellable("task_1", "commit"),
+		"RequestReplayed":            responseutils.RequestReplayed(),
+		"UnsupportedVersion":         responseutils.UnsupportedVersion("order", 3, []int{1, 2}),
+		"BadRequest":                 responseutils.BadRequest("bad"),
+		"Unauthorized":               responseutils.Unauthorized("who are you"),
+		"Forbidden":                  responseutils.Forbidden("no"),
+		"NotFound":                   responseutils.NotFound("order"),
+		"Conflict":                   responseutils.Conflict("taken"),
+		"ValidationError":            responseutils.ValidationError("invalid"),
+		"InternalServerError":        responseutils.InternalServerError("oops"),
+		"DatabaseError":              responseutils.DatabaseError(errors.New("connection reset")),
+		"InvalidInput":               responseutils.InvalidInput("name", "too long"),
+		"MissingHeader":              responseutils.MissingHeader("X-Request-ID"),
+		"InvalidUUID":                responseutils.InvalidUUID("id"),
+		"DuplicateEntry":             responseutils.DuplicateEntry("user"),
+		"ForeignKeyViolation":        responseutils.ForeignKeyViolation("order has lines"),
+		"UnauthorizedError":          responseutils.UnauthorizedError("expired"),
+		"VersionExistsError":         responseutils.VersionExistsError("2"),
+		"UnavailableForLegalReasons": responseutils.UnavailableForLegalReasons("court order", "High Court"),
+		"PaymentRequired":            responseutils.PaymentRequired("upgrade", "pro"),
+		"InsufficientScope":          responseutils.InsufficientScope([]string{"orders:write"}),
+		"InvalidSearchCursor":        responseutils.InvalidSearchCursor(),
+		"InvalidStateTransition":     responseutils.InvalidStateTransition("order", "shipped", "draft", []string{"delivered"}),
+		"InvalidSyncToken":           responseutils.InvalidSyncToken(),
+		"SyncTokenExpired":           responseutils.SyncTokenExpired(),
+		"ResourceGone":               responseutils.ResourceGone("order_1", now),
+		"InvalidEnumValue":           responseutils.InvalidEnumValue("status", "lost", []string{"open", "closed"}),
+		"InvalidDateRange":           responseutils.InvalidDateRange(now, now.Add(-time.Hour)),
+		"MissingWebhookSignature":    responseutils.MissingWebhookSignature("X-Signature"),
+		"InvalidWebhookSignature":    responseutils.InvalidWebhookSignature("mismatch"),
+		"WebhookSignatureExpired":    responseutils.WebhookSignatureExpired(now, 5*time.Minute),
+	}
+
+	for name, err := range constructors {
+		for _, write := range []func(*gin.Context, error){responseutils.ErrorResponse, responseutils.ProblemResponse} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/invariants", nil)
+			write(c, err)
+
+			r := &responseutils.CapturedResponse{StatusCode: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+			for _, v := range Check(r, Options{}) {
+				t.Errorf("%s: %s", name, v)
+			}
+		}
+	}
+}
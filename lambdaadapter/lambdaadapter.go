@@ -0,0 +1,206 @@
+// Package lambdaadapter runs gin engines using the response-utils envelope
+// behind AWS API Gateway, converting Lambda proxy events (REST API payload
+// version 1.0 and HTTP API payload version 2.0) to HTTP requests and the
+// captured responses back to their events types.
+package lambdaadapter
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// Handler adapts h, typically a *gin.Engine, to a REST API (payload 1.0) Lambda handler
+func Handler(h http.Handler) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		req, err := Request(ctx, event)
+		if err != nil {
+			return Error(responseutils.ErrInvalidBody.WithDetails("error", err.Error())), nil
+		}
+		return Response(responseutils.CaptureResponse(h, req)), nil
+	}
+}
+
+// HandlerV2 adapts h, typically a *gin.Engine, to an HTTP API (payload 2.0) Lambda handler
+func HandlerV2(h http.Handler) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		req, err := RequestV2(ctx, event)
+		if err != nil {
+			return ErrorV2(responseutils.ErrInvalidBody.WithDetails("error", err.Error())), nil
+		}
+		return ResponseV2(responseutils.CaptureResponse(h, req)), nil
+	}
+}
+
+// Request converts a REST API proxy event to an HTTP request
+func Request(ctx context.Context, event events.APIGatewayProxyRequest) (*http.Request, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for k, v := range event.QueryStringParameters {
+		query.Set(k, v)
+	}
+	for k, v := range event.MultiValueQueryStringParameters {
+		query[k] = append([]string(nil), v...)
+	}
+
+	req, err := newRequest(ctx, event.HTTPMethod, event.Path, query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range event.MultiValueHeaders {
+		req.Header.Del(k)
+		for _, value := range v {
+			req.Header.Add(k, value)
+		}
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	req.RemoteAddr = remoteAddr(event.RequestContext.Identity.SourceIP)
+
+	return req, nil
+}
+
+// RequestV2 converts an HTTP API proxy event to an HTTP request
+func RequestV2(ctx context.Context, event events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(ctx, event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// HTTP APIs join repeated headers with commas, which is equivalent
+	// to the repeated form for list-valued headers
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	if event.RequestContext.DomainName != "" {
+		req.Host = event.RequestContext.DomainName
+	}
+	req.RemoteAddr = remoteAddr(event.RequestContext.HTTP.SourceIP)
+
+	return req, nil
+}
+
+// Response converts a captured response to a REST API proxy response,
+// including multi-value headers and base64 encoding for binary bodies
+func Response(r *responseutils.CapturedResponse) events.APIGatewayProxyResponse {
+	proxy := r.APIGatewayProxy()
+
+	return events.APIGatewayProxyResponse{
+		StatusCode:        proxy.StatusCode,
+		Headers:           proxy.Headers,
+		MultiValueHeaders: proxy.MultiValueHeaders,
+		Body:              proxy.Body,
+		IsBase64Encoded:   proxy.IsBase64Encoded,
+	}
+}
+
+// ResponseV2 converts a captured response to an HTTP API proxy response.
+// Repeated headers are joined with commas and Set-Cookie headers are moved
+// to the cookies list, as payload version 2.0 requires.
+func ResponseV2(r *responseutils.CapturedResponse) events.APIGatewayV2HTTPResponse {
+	proxy := r.APIGatewayProxy()
+
+	out := events.APIGatewayV2HTTPResponse{
+		StatusCode:      proxy.StatusCode,
+		Headers:         make(map[string]string, len(proxy.MultiValueHeaders)),
+		Body:            proxy.Body,
+		IsBase64Encoded: proxy.IsBase64Encoded,
+	}
+
+	for k, v := range proxy.MultiValueHeaders {
+		if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+			out.Cookies = append(out.Cookies, v...)
+			continue
+		}
+		out.Headers[k] = strings.Join(v, ",")
+	}
+
+	return out
+}
+
+// Error converts err to a REST API proxy response carrying the standard error envelope
+func Error(err error) events.APIGatewayProxyResponse {
+	return Response(captureError(err))
+}
+
+// ErrorV2 converts err to an HTTP API proxy response carrying the standard error envelope
+func ErrorV2(err error) events.APIGatewayV2HTTPResponse {
+	return ResponseV2(captureError(err))
+}
+
+// captureError writes err with responseutils.ErrorResponse outside of any router
+func captureError(err error) *responseutils.CapturedResponse {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	responseutils.ErrorResponse(c, err)
+
+	return &responseutils.CapturedResponse{
+		StatusCode: rec.Code,
+		Header:     rec.Header(),
+		Body:       rec.Body.Bytes(),
+	}
+}
+
+func newRequest(ctx context.Context, method, path, rawQuery, body string) (*http.Request, error) {
+	if path == "" {
+		path = "/"
+	}
+	target := path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = target
+	return req, nil
+}
+
+func decodeBody(body string, isBase64 bool) (string, error) {
+	if !isBase64 {
+		return body, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// remoteAddr gives the source IP the host:port form of http.Request's
+// RemoteAddr, which gin's ClientIP splits; the source port is unknown
+func remoteAddr(sourceIP string) string {
+	if sourceIP == "" {
+		return ""
+	}
+	return net.JoinHostPort(sourceIP, "0")
+}
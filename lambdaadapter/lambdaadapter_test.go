@@ -0,0 +1,39 @@
+package lambdaadapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIP(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	var got string
+	engine := gin.New()
+	engine.GET("/ip", func(c *gin.Context) { got = c.ClientIP() })
+
+	for _, sourceIP := range []string{"203.0.113.7", "2001:db8::1"} {
+		v1 := events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/ip"}
+		v1.RequestContext.Identity.SourceIP = sourceIP
+		got = ""
+		if _, err := Handler(engine)(context.Background(), v1); err != nil {
+			t.Fatal(err)
+		}
+		if got != sourceIP {
+			t.Errorf("payload 1.0: ClientIP() = %q, want %q", got, sourceIP)
+		}
+
+		v2 := events.APIGatewayV2HTTPRequest{RawPath: "/ip"}
+		v2.RequestContext.HTTP.Method = "GET"
+		v2.RequestContext.HTTP.SourceIP = sourceIP
+		got = ""
+		if _, err := HandlerV2(engine)(context.Background(), v2); err != nil {
+			t.Fatal(err)
+		}
+		if got != sourceIP {
+			t.Errorf("payload 2.0: ClientIP() = %q, want %q", got, sourceIP)
+		}
+	}
+}
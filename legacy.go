@@ -0,0 +1,232 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyRule describes how a route's legacy payloads map onto the envelope.
+// Paths are dot-separated keys into the legacy body, e.g. "result.items".
+type LegacyRule struct {
+	// DataPath locates the data of success bodies; empty means the whole body
+	DataPath string
+	// MessagePath locates the message of success bodies, if any
+	MessagePath string
+	// ErrorMessagePath locates the message of error bodies; by default
+	// "message" or "error" is used when it holds a string
+	ErrorMessagePath string
+	// ErrorCodePath locates the error code of error bodies; without one the
+	// generic code of the status is used, see ErrorForStatus
+	ErrorCodePath string
+	// DetailsPath locates an object sent as the error details
+	DetailsPath string
+	// Transform replaces the path mapping for bodies that need more than
+	// moving fields, e.g. legacy 200 responses with {"ok": false}. A non-nil
+	// error is sent as the error envelope.
+	Transform func(statusCode int, body interface{}) (data interface{}, message string, err error)
+}
+
+// LegacyRules map routes, in the ContractSchema form "GET /orders/:id", to
+// their rewrite rules
+type LegacyRules map[string]LegacyRule
+
+// LegacyInterceptor returns middleware rewriting the payloads of handlers
+// not yet migrated into the standard envelope, so a service can move on to
+// response-utils route by route. On routes with a rule the response is
+// buffered; JSON bodies the handler wrote directly, e.g. with c.JSON, are
+// rewritten with the rule and sent through SuccessResponse or, for 4xx and
+// 5xx statuses, ErrorResponse. Bodies written by this package's writers or
+// already shaped like the envelope, e.g. by the JSON render, non-JSON bodies
+// and bodiless responses are sent unchanged, so a rule can stay in place
+// while its route is migrated.
+//
+// Do not use it on streaming routes, as their output would be buffered.
+func LegacyInterceptor(rules LegacyRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := rules[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &legacyWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = original
+
+		if _, migrated := WrittenEnvelope(c); migrated || !legacyJSON(w) {
+			w.flush()
+			return
+		}
+		var body interface{}
+		dec := json.NewDecoder(bytes.NewReader(w.body.Bytes()))
+		dec.UseNumber()
+		if err := dec.Decode(&body); err != nil || isEnvelope(body) {
+			w.flush()
+			return
+		}
+
+		header := original.Header()
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		data, message, err := rule.rewrite(w.status, body)
+		if err != nil {
+			ErrorResponse(c, err)
+			return
+		}
+		SuccessResponse(c, w.status, data, message)
+	}
+}
+
+func (r LegacyRule) rewrite(statusCode int, body interface{}) (interface{}, string, error) {
+	if r.Transform != nil {
+		return r.Transform(statusCode, body)
+	}
+
+	if statusCode < http.StatusBadRequest {
+		var message string
+		if r.MessagePath != "" {
+			message, _ = legacyValue(body, r.MessagePath).(string)
+		}
+		return legacyValue(body, r.DataPath), message, nil
+	}
+
+	var message string
+	if r.ErrorMessagePath != "" {
+		message, _ = legacyValue(body, r.ErrorMessagePath).(string)
+	} else {
+		for _, path := range []string{"message", "error"} {
+			if s, ok := legacyValue(body, path).(string); ok {
+				message = s
+				break
+			}
+		}
+	}
+	err := ErrorForStatus(statusCode, message)
+	if r.ErrorCodePath != "" {
+		if code, ok := legacyValue(body, r.ErrorCodePath).(string); ok && code != "" {
+			err.Code = code
+		}
+	}
+	if r.DetailsPath != "" {
+		if details, ok := legacyValue(body, r.DetailsPath).(map[string]interface{}); ok {
+			err.Details = Details(details)
+		}
+	}
+	return nil, "", err
+}
+
+// legacyValue returns the value at a dot-separated path, or the whole body
+// for an empty path
+func legacyValue(body interface{}, path string) interface{} {
+	if path == "" {
+		return body
+	}
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := body.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		body = obj[key]
+	}
+	return body
+}
+
+// envelopeMembers are the top-level members an envelope may have
+var envelopeMembers = map[string]bool{
+	"success": true, "data": true, "error": true, "message": true, "meta": true,
+	"links": true, "pagination": true, "degraded": true, "unavailable": true,
+}
+
+// isEnvelope reports whether a decoded body already is an envelope: a
+// boolean success, only envelope members and, for errors, an error code
+func isEnvelope(body interface{}) bool {
+	obj, ok := body.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	success, ok := obj["success"].(bool)
+	if !ok {
+		return false
+	}
+	for k := range obj {
+		if !envelopeMembers[k] {
+			return false
+		}
+	}
+	if success {
+		return obj["error"] == nil
+	}
+	e, _ := obj["error"].(map[string]interface{})
+	code, _ := e["code"].(string)
+	return code != ""
+}
+
+func legacyJSON(w *legacyWriter) bool {
+	if w.body.Len() == 0 || !bodyAllowedForStatus(w.status) {
+		return false
+	}
+	contentType := w.Header().Get("Content-Type")
+	return contentType == "" || strings.Contains(contentType, "json")
+}
+
+// legacyWriter buffers a handler's response until LegacyInterceptor decides
+// whether to rewrite it
+type legacyWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *legacyWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *legacyWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *legacyWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *legacyWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *legacyWriter) Status() int {
+	return w.status
+}
+
+func (w *legacyWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *legacyWriter) Written() bool {
+	return w.written
+}
+
+func (w *legacyWriter) Flush() {}
+
+// flush sends the buffered response unchanged
+func (w *legacyWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,30 @@
+package responseutils
+
+import "github.com/gin-gonic/gin"
+
+const linksKey = "responseutils.links"
+
+// Link represents a hypermedia link in the envelope's links block
+type Link struct {
+	Rel    string `json:"rel" example:"self"`
+	Href   string `json:"href" example:"/users/123"`
+	Method string `json:"method,omitempty" example:"GET"`
+	Type   string `json:"type,omitempty" example:"application/json"`
+}
+
+// AddLink adds a link to the links block of the response written for this request
+func AddLink(c *gin.Context, rel, href string) {
+	AddLinks(c, Link{Rel: rel, Href: href})
+}
+
+// AddLinks adds links to the links block of the response written for this request
+func AddLinks(c *gin.Context, links ...Link) {
+	c.Set(linksKey, append(RequestLinks(c), links...))
+}
+
+// RequestLinks returns the links added for this request
+func RequestLinks(c *gin.Context) []Link {
+	links, _ := c.Get(linksKey)
+	l, _ := links.([]Link)
+	return l
+}
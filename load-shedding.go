@@ -0,0 +1,194 @@
+package responseutils
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Shed reasons reported in the details of SERVICE_OVERLOADED errors
+const (
+	ShedReasonQueueFull    = "queue_full"
+	ShedReasonQueueTimeout = "queue_timeout"
+	ShedReasonCPU          = "cpu"
+)
+
+func Overloaded(reason string, retryAfter time.Duration) *ResponseError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return NewResponseError(ErrCodeServiceOverloaded, "Service is overloaded, please retry later", http.StatusServiceUnavailable).
+		WithDetails("shed_reason", reason).
+		WithDetails("retry_after_seconds", seconds).
+		WithHeader("Retry-After", strconv.Itoa(seconds))
+}
+
+// LoadSheddingOptions configure a LoadShedder
+type LoadSheddingOptions struct {
+	// MaxInFlight is the number of requests handled concurrently; further
+	// requests queue. 0 disables queue-based shedding.
+	MaxInFlight int
+	// MaxQueue is the number of requests allowed to wait for a slot; requests
+	// beyond it are shed immediately
+	MaxQueue int
+	// MaxQueueWait is how long a queued request waits before being shed;
+	// defaults to 100ms
+	MaxQueueWait time.Duration
+	// CPUThreshold is the CPU utilization (0-1) above which requests are shed
+	// with a probability rising linearly to 1 at full utilization. 0 disables
+	// CPU-based shedding.
+	CPUThreshold float64
+	// CPU returns the current CPU utilization (0-1); nil measures the
+	// process's CPU time where the platform supports it
+	CPU func() float64
+	// RetryAfter is sent to shed clients; defaults to 1s
+	RetryAfter time.Duration
+	// Exempt requests are never shed, e.g. health checks
+	Exempt func(c *gin.Context) bool
+}
+
+// LoadShedder rejects excess requests early with a 503 while the service is
+// under pressure, protecting the tail latency of the requests it accepts
+type LoadShedder struct {
+	opts   LoadSheddingOptions
+	slots  chan struct{}
+	queued atomic.Int64
+	shed   atomic.Int64
+}
+
+// NewLoadShedder creates a load shedder
+func NewLoadShedder(opts LoadSheddingOptions) *LoadShedder {
+	if opts.MaxQueueWait <= 0 {
+		opts.MaxQueueWait = 100 * time.Millisecond
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+	if opts.CPU == nil {
+		opts.CPU = newCPUSampler().utilization
+	}
+
+	s := &LoadShedder{opts: opts}
+	if opts.MaxInFlight > 0 {
+		s.slots = make(chan struct{}, opts.MaxInFlight)
+	}
+	return s
+}
+
+// InFlight returns the number of requests being handled
+func (s *LoadShedder) InFlight() int {
+	return len(s.slots)
+}
+
+// Queued returns the number of requests waiting for a slot
+func (s *LoadShedder) Queued() int {
+	return int(s.queued.Load())
+}
+
+// Shed returns the number of requests shed so far
+func (s *LoadShedder) Shed() int64 {
+	return s.shed.Load()
+}
+
+// Middleware sheds requests when the CPU is over its threshold or no slot
+// frees up in time, with a SERVICE_OVERLOADED 503 and Retry-After
+func (s *LoadShedder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.opts.Exempt != nil && s.opts.Exempt(c) {
+			c.Next()
+			return
+		}
+
+		if t := s.opts.CPUThreshold; t > 0 && t < 1 {
+			if u := s.opts.CPU(); u > t && rand.Float64() < (u-t)/(1-t) {
+				s.reject(c, ShedReasonCPU)
+				return
+			}
+		}
+
+		if s.slots != nil {
+			if reason := s.acquire(c); reason != "" {
+				s.reject(c, reason)
+				return
+			}
+			if c.IsAborted() {
+				return
+			}
+			defer func() { <-s.slots }()
+		}
+		c.Next()
+	}
+}
+
+// acquire takes a slot, queueing for up to MaxQueueWait, and returns the
+// shed reason when none could be taken. It aborts without a reason when the
+// client goes away while queued.
+func (s *LoadShedder) acquire(c *gin.Context) string {
+	select {
+	case s.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if s.queued.Add(1) > int64(s.opts.MaxQueue) {
+		s.queued.Add(-1)
+		return ShedReasonQueueFull
+	}
+	defer s.queued.Add(-1)
+
+	timer := time.NewTimer(s.opts.MaxQueueWait)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return ""
+	case <-timer.C:
+		return ShedReasonQueueTimeout
+	case <-c.Request.Context().Done():
+		c.Abort()
+		return ""
+	}
+}
+
+func (s *LoadShedder) reject(c *gin.Context, reason string) {
+	s.shed.Add(1)
+	ErrorResponse(c, Overloaded(reason, s.opts.RetryAfter))
+	c.Abort()
+}
+
+// cpuSampler measures the process's CPU utilization across GOMAXPROCS,
+// refreshing it at most every 250ms
+type cpuSampler struct {
+	mu      sync.Mutex
+	at      time.Time
+	cpu     time.Duration
+	current float64
+}
+
+func newCPUSampler() *cpuSampler {
+	cpu, _ := processCPUTime()
+	return &cpuSampler{at: time.Now(), cpu: cpu}
+}
+
+func (s *cpuSampler) utilization() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(s.at)
+	if elapsed < 250*time.Millisecond {
+		return s.current
+	}
+	cpu, ok := processCPUTime()
+	if !ok {
+		return 0
+	}
+	s.current = float64(cpu-s.cpu) / (float64(elapsed) * float64(runtime.GOMAXPROCS(0)))
+	s.current = min(max(s.current, 0), 1)
+	s.at, s.cpu = now, cpu
+	return s.current
+}
@@ -0,0 +1,83 @@
+package responseutils
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnsupportedMediaType creates a 415 error listing the supported content types
+func UnsupportedMediaType(contentType string, supported []string) *ResponseError {
+	return NewResponseError(
+		ErrCodeUnsupportedMediaType,
+		fmt.Sprintf("Content type '%s' is not supported", contentType),
+		http.StatusUnsupportedMediaType,
+	).WithDetails("supported", supported)
+}
+
+// NotAcceptable creates a 406 error listing the media types the server can produce
+func NotAcceptable(supported []string) *ResponseError {
+	return NewResponseError(
+		ErrCodeNotAcceptable,
+		"None of the requested media types can be produced",
+		http.StatusNotAcceptable,
+	).WithDetails("supported", supported)
+}
+
+// RequireContentType returns middleware rejecting requests with a body whose
+// Content-Type is not one of the allowed types (wildcards such as
+// "application/*" are accepted) with the standard 415 envelope
+func RequireContentType(allowed ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		contentType := c.GetHeader("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			for _, a := range allowed {
+				if mediaTypeMatches(a, mediaType) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		ErrorResponse(c, UnsupportedMediaType(contentType, allowed))
+		c.Abort()
+	}
+}
+
+// RequireAccept returns middleware negotiating the response media type from
+// the supported types. Unsatisfiable Accept headers are handled by the
+// negotiation policy: a 406 envelope when strict, or the first supported type
+// with a Warning header. The negotiated type is available through NegotiatedType.
+func RequireAccept(supported ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType, ok := negotiate(c, supported...)
+		if !ok {
+			ErrorResponse(c, NotAcceptable(supported))
+			c.Abort()
+			return
+		}
+
+		c.Set(negotiatedTypeKey, mediaType)
+		c.Next()
+	}
+}
+
+const negotiatedTypeKey = "responseutils.negotiated_type"
+
+// NegotiatedType returns the media type negotiated by RequireAccept
+func NegotiatedType(c *gin.Context) string {
+	return c.GetString(negotiatedTypeKey)
+}
+
+// hasBody reports whether a request carries a body
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && (r.ContentLength > 0 || r.ContentLength == -1)
+}
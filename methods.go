@@ -0,0 +1,96 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MethodNotAllowed creates a 405 error listing the permitted methods
+func MethodNotAllowed(method string, allowed []string) *ResponseError {
+	return NewResponseError(
+		ErrCodeMethodNotAllowed,
+		fmt.Sprintf("Method %s is not allowed for this resource", method),
+		http.StatusMethodNotAllowed,
+	).WithDetails("allowed_methods", allowed)
+}
+
+// MethodNotAllowedResponse sends a 405 error with the Allow header set
+func MethodNotAllowedResponse(c *gin.Context, allowed []string) {
+	c.Header("Allow", strings.Join(allowed, ", "))
+	ErrorResponse(c, MethodNotAllowed(c.Request.Method, allowed))
+}
+
+// NoMethodHandler returns a handler for gin's NoMethod that answers OPTIONS
+// requests with 204 and an Allow header built from the engine's route table,
+// and any other wrong-method request with the standard 405 envelope
+func NoMethodHandler(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := AllowedMethods(engine, c.Request.URL.Path)
+
+		if c.Request.Method == http.MethodOptions {
+			c.Header("Allow", strings.Join(allowed, ", "))
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		MethodNotAllowedResponse(c, allowed)
+		c.Abort()
+	}
+}
+
+// InstallMethodHandlers enables gin's method-not-allowed handling on the
+// engine and installs NoMethodHandler, replacing gin's default 404s for
+// wrong-method requests
+func InstallMethodHandlers(engine *gin.Engine) {
+	engine.HandleMethodNotAllowed = true
+	engine.NoMethod(NoMethodHandler(engine))
+}
+
+// AllowedMethods returns the methods registered on the engine for a request
+// path, including OPTIONS, in sorted order
+func AllowedMethods(engine *gin.Engine, path string) []string {
+	seen := map[string]bool{http.MethodOptions: true}
+	for _, route := range engine.Routes() {
+		if routeMatches(route.Path, path) {
+			seen[route.Method] = true
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// routeMatches reports whether a request path matches a gin route pattern
+// with :param and *wildcard segments
+func routeMatches(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+
+	return len(patternParts) == len(pathParts)
+}
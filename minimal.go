@@ -0,0 +1,65 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	textContentType = "text/plain; charset=utf-8"
+	minimalModeKey  = "responseutils.minimal_mode"
+)
+
+// MinimalMode returns middleware for infrastructure endpoints such as health
+// and version checks. Success responses on these routes are sent as
+// text/plain bodies when the client negotiates text/plain; when plainByDefault
+// is set, clients without an explicit JSON preference (no Accept header or
+// */*) get text/plain too. Errors remain structured envelopes.
+func MinimalMode(plainByDefault bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(minimalModeKey, plainByDefault)
+		c.Next()
+	}
+}
+
+// TextResponse sends a text/plain response
+func TextResponse(c *gin.Context, statusCode int, text string) {
+	writeBody(c, statusCode, textContentType, []byte(text))
+}
+
+// writeMinimal writes a success response as plain text when the route is in
+// minimal mode and the client negotiated text/plain, reporting whether it did
+func writeMinimal(c *gin.Context, statusCode int, data interface{}, message string) bool {
+	plainByDefault, ok := c.Get(minimalModeKey)
+	if !ok || c.Request == nil {
+		return false
+	}
+
+	offered := []string{"application/json", "text/plain"}
+	if plainByDefault.(bool) {
+		offered = []string{"text/plain", "application/json"}
+	}
+	if mediaType, _ := NegotiateMediaType(c.GetHeader("Accept"), offered...); mediaType != "text/plain" {
+		return false
+	}
+
+	TextResponse(c, statusCode, minimalText(statusCode, data, message))
+	return true
+}
+
+// minimalText returns the plain text body for a success response: string or
+// fmt.Stringer data, then the message, then the status text
+func minimalText(statusCode int, data interface{}, message string) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	if message != "" {
+		return message
+	}
+	return http.StatusText(statusCode)
+}
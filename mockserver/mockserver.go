@@ -0,0 +1,310 @@
+// Package mockserver builds a gin mock server from a service's routes and
+// example payloads, so client teams can integration-test against realistic
+// envelopes in CI without the real backend.
+//
+// Each route answers with its fixture; fixtures are written by hand, loaded
+// with LoadFixtures or taken from recorded production exchanges with
+// FixturesFromRecordings. Any request can instead ask for a registered
+// error code, so clients can exercise their error handling:
+//
+//	curl -H 'X-Mock-Error: NOT_FOUND' localhost:8080/orders/42
+package mockserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Request headers controlling the mock's answer
+const (
+	// ErrorHeader makes the mock answer with a registered error code, e.g.
+	// "NOT_FOUND", sent as ErrorResponse would send it
+	ErrorHeader = "X-Mock-Error"
+	// StatusHeader picks the route's fixture with that status code
+	StatusHeader = "X-Mock-Status"
+)
+
+// ErrCodeNoFixture is answered, with 501, for routes without a fixture
+const ErrCodeNoFixture = "MOCK_NO_FIXTURE"
+
+// Fixture is an example response for a route
+type Fixture struct {
+	Method string `json:"method"`
+	// Route is the gin path pattern, e.g. "/orders/:id"
+	Route  string          `json:"route"`
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// Options configure New
+type Options struct {
+	// Routes are served even without a fixture, in the ContractSchema form
+	// "GET /orders/:id"; see Routes for taking them from an engine
+	Routes []string
+	// Fixtures are the example responses. When a route has several, the
+	// first 2xx fixture is the default and StatusHeader picks another.
+	Fixtures []Fixture
+	// Latency delays every response, to exercise client timeouts
+	Latency time.Duration
+}
+
+// Routes returns the routes registered on an engine, for Options.Routes
+func Routes(engine *gin.Engine) []string {
+	var routes []string
+	for _, r := range engine.Routes() {
+		routes = append(routes, r.Method+" "+r.Path)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// New returns a mock server serving the routes and fixtures
+func New(opts Options) *gin.Engine {
+	fixtures := map[string][]Fixture{}
+	var order []string
+	add := func(route string) {
+		if _, ok := fixtures[route]; !ok {
+			fixtures[route] = nil
+			order = append(order, route)
+		}
+	}
+	for _, route := range opts.Routes {
+		add(route)
+	}
+	for _, f := range opts.Fixtures {
+		route := strings.ToUpper(f.Method) + " " + f.Route
+		add(route)
+		fixtures[route] = append(fixtures[route], f)
+	}
+
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	for _, route := range order {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok {
+			panic(fmt.Sprintf("mockserver: route %q is not of the form \"METHOD /path\"", route))
+		}
+		engine.Handle(method, path, handler(route, fixtures[route], opts.Latency))
+	}
+	engine.NoRoute(responseutils.NoRouteHandler(engine))
+	return engine
+}
+
+func handler(route string, fixtures []Fixture, latency time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+
+		if code := c.GetHeader(ErrorHeader); code != "" {
+			info, ok := responseutils.LookupErrorCode(code)
+			if !ok {
+				responseutils.ErrorResponse(c, responseutils.InvalidInput(ErrorHeader, "is not a registered error code"))
+				return
+			}
+			responseutils.ErrorResponse(c, responseutils.NewResponseError(info.Code, info.Description, info.StatusCode))
+			return
+		}
+
+		f, err := pick(route, fixtures, c.GetHeader(StatusHeader))
+		if err != nil {
+			responseutils.ErrorResponse(c, err)
+			return
+		}
+		for k, values := range f.Header {
+			for _, v := range values {
+				c.Writer.Header().Add(k, v)
+			}
+		}
+		contentType := c.Writer.Header().Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/json; charset=utf-8"
+		}
+		c.Data(f.Status, contentType, f.Body)
+	}
+}
+
+func pick(route string, fixtures []Fixture, status string) (Fixture, error) {
+	if len(fixtures) == 0 {
+		return Fixture{}, responseutils.NewResponseError(
+			ErrCodeNoFixture,
+			fmt.Sprintf("The mock server has no fixture for %s", route),
+			http.StatusNotImplemented,
+		)
+	}
+
+	if status != "" {
+		want, err := strconv.Atoi(status)
+		if err != nil {
+			return Fixture{}, responseutils.InvalidInput(StatusHeader, "must be a status code")
+		}
+		for _, f := range fixtures {
+			if f.Status == want {
+				return f, nil
+			}
+		}
+		return Fixture{}, responseutils.NewResponseError(
+			ErrCodeNoFixture,
+			fmt.Sprintf("The mock server has no %d fixture for %s", want, route),
+			http.StatusNotImplemented,
+		).WithDetails("statuses", statuses(fixtures))
+	}
+
+	for _, f := range fixtures {
+		if f.Status >= 200 && f.Status < 300 {
+			return f, nil
+		}
+	}
+	return fixtures[0], nil
+}
+
+func statuses(fixtures []Fixture) []int {
+	var s []int
+	for _, f := range fixtures {
+		s = append(s, f.Status)
+	}
+	return s
+}
+
+// LoadFixtures reads a JSON array of fixtures
+func LoadFixtures(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, err
+	}
+	for i, f := range fixtures {
+		if f.Method == "" || f.Route == "" {
+			return nil, fmt.Errorf("mockserver: fixture %d needs a method and route", i)
+		}
+		if f.Status == 0 {
+			fixtures[i].Status = http.StatusOK
+		}
+	}
+	return fixtures, nil
+}
+
+// FixturesFromRecordings turns recorded exchanges, e.g. from a RingSink,
+// into fixtures. Recorded URLs are matched against routes so that
+// /orders/42 becomes a fixture for "GET /orders/:id"; exchanges matching
+// none of them keep their literal path. The first recording of each route
+// and status is kept, and truncated or bodiless recordings are skipped.
+// Compressed bodies are decoded, since fixtures hold the JSON itself;
+// recordings in a coding that cannot be decoded, such as br, are skipped.
+// Record with field redaction enabled, as fixtures are shared with clients.
+func FixturesFromRecordings(exchanges []*responseutils.RecordedExchange, routes []string) []Fixture {
+	match := routeMatcher(routes)
+	seen := map[string]bool{}
+
+	var fixtures []Fixture
+	for _, x := range exchanges {
+		if x.Truncated || len(x.ResponseBody) == 0 {
+			continue
+		}
+		u, err := url.Parse(x.URL)
+		if err != nil {
+			continue
+		}
+		body, err := decodeBody(x.ResponseBody, x.ResponseHeader.Get("Content-Encoding"))
+		if err != nil {
+			continue
+		}
+		route := match(x.Method, u.Path)
+		key := x.Method + " " + route + " " + strconv.Itoa(x.StatusCode)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		header := http.Header{}
+		if ct := x.ResponseHeader.Get("Content-Type"); ct != "" {
+			header.Set("Content-Type", ct)
+		}
+		fixtures = append(fixtures, Fixture{
+			Method: x.Method,
+			Route:  route,
+			Status: x.StatusCode,
+			Header: header,
+			Body:   json.RawMessage(body),
+		})
+	}
+	return fixtures
+}
+
+// decodeBody undoes the content codings of a recorded body, last applied
+// first
+func decodeBody(body []byte, contentEncoding string) ([]byte, error) {
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var r io.Reader
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			r = zr
+		case "deflate":
+			zr, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			r = zr
+		case "zstd":
+			zr, err := zstd.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			r = zr
+		default:
+			return nil, fmt.Errorf("mockserver: unsupported content coding %q", coding)
+		}
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+	}
+	return body, nil
+}
+
+// routeMatcher resolves paths to route patterns with gin's own router, so
+// matching follows the same precedence as the real service
+func routeMatcher(routes []string) func(method, path string) string {
+	engine := gin.New()
+	for _, route := range routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok {
+			continue
+		}
+		engine.Handle(method, path, func(c *gin.Context) {
+			c.Header("X-Route", c.FullPath())
+		})
+	}
+	return func(method, path string) string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, (&url.URL{Path: path}).RequestURI(), nil))
+		if route := w.Header().Get("X-Route"); route != "" {
+			return route
+		}
+		return path
+	}
+}
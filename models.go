@@ -1,6 +1,9 @@
 package responseutils
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+)
 
 // Response represents a standard API response
 // @Description Standard API response structure
@@ -42,6 +45,16 @@ type CreatedResponseDTO struct {
 	Message string      `json:"message" example:"Resource created successfully"`
 }
 
+// DegradedResponseDTO represents a partial result where some components were unavailable
+// @Description Degraded response structure
+type DegradedResponseDTO struct {
+	Success     bool        `json:"success" example:"true"`
+	Data        interface{} `json:"data,omitempty"`
+	Degraded    bool        `json:"degraded" example:"true"`
+	Unavailable []string    `json:"unavailable"`
+	Message     string      `json:"message,omitempty" example:"Some components are unavailable"`
+}
+
 // ListResponse represents a paginated list response
 type ListResponse struct {
 	Success    bool        `json:"success"`
@@ -59,10 +72,13 @@ type Pagination struct {
 
 // AppError represents an application-specific error
 type ResponseError struct {
-	Code       string                 `json:"code"`
-	Message    string                 `json:"message"`
-	StatusCode int                    `json:"-"`
-	Details    map[string]interface{} `json:"details,omitempty"`
+	Code       string  `json:"code"`
+	Message    string  `json:"message"`
+	StatusCode int     `json:"-"`
+	Details    Details `json:"details,omitempty"`
+	headers    http.Header
+	frozen     bool
+	pooled     bool
 }
 
 // Error implements the error interface
@@ -0,0 +1,187 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Money is an amount in the minor units of an ISO 4217 currency, e.g. 1999
+// GBP for £19.99. It is encoded in JSON as {"amount": 1999, "currency":
+// "GBP"}; decoding rejects fractional amounts and malformed currencies so
+// floats never enter financial code.
+// @Description Monetary amount in minor units
+type Money struct {
+	Amount   int64  `json:"amount" example:"1999"`
+	Currency string `json:"currency" example:"GBP"`
+}
+
+// NewMoney creates an amount in minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal amount in major units, such as "19.99", with
+// no more decimal places than the currency has
+func ParseMoney(decimal string, currency string) (Money, *ResponseError) {
+	currency = strings.ToUpper(currency)
+	if !validCurrency(currency) {
+		return Money{}, InvalidCurrency(currency)
+	}
+
+	exp := CurrencyExponent(currency)
+	s := strings.TrimSpace(decimal)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > exp || strings.Trim(whole+frac, "0123456789") != "" {
+		return Money{}, InvalidAmount(decimal, fmt.Sprintf("must be a decimal with at most %d decimal places", exp))
+	}
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, InvalidAmount(decimal, "is out of range")
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// CurrencyExponent returns the number of decimal places of a currency's
+// minor unit, 2 for currencies not known to differ
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Decimal formats the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "19.99 GBP"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, *ResponseError) {
+	if m.Currency != other.Currency {
+		return Money{}, CurrencyMismatch(m.Currency, other.Currency)
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, InvalidAmount(m.String(), "sum is out of range")
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of two amounts in the same currency
+func (m Money) Sub(other Money) (Money, *ResponseError) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, InvalidAmount(other.String(), "is out of range")
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting only an integer
+// amount and a three-letter currency code
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return InvalidAmount(string(data), "must be an object with amount and currency")
+	}
+
+	amount, err := strconv.ParseInt(raw.Amount.String(), 10, 64)
+	if err != nil {
+		return InvalidAmount(raw.Amount.String(), "must be an integer number of minor units")
+	}
+	currency := strings.ToUpper(raw.Currency)
+	if !validCurrency(currency) {
+		return InvalidCurrency(raw.Currency)
+	}
+	*m = Money{Amount: amount, Currency: currency}
+	return nil
+}
+
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for i := 0; i < 3; i++ {
+		if currency[i] < 'A' || currency[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
+
+func InvalidAmount(amount string, reason string) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidAmount,
+		fmt.Sprintf("Invalid amount '%s': %s", amount, reason),
+		http.StatusBadRequest,
+	).WithDetails("amount", amount)
+}
+
+func InvalidCurrency(currency string) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidAmount,
+		fmt.Sprintf("Invalid currency '%s': must be a three-letter ISO 4217 code", currency),
+		http.StatusBadRequest,
+	).WithDetails("currency", currency)
+}
+
+func CurrencyMismatch(expected, actual string) *ResponseError {
+	return NewResponseError(
+		ErrCodeCurrencyMismatch,
+		fmt.Sprintf("Currency '%s' does not match '%s'", actual, expected),
+		http.StatusUnprocessableEntity,
+	).WithDetails("expected_currency", expected).WithDetails("currency", actual)
+}
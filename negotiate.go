@@ -0,0 +1,122 @@
+package responseutils
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single media range from an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+	order     int
+}
+
+// parseAccept parses an Accept header into media ranges sorted by
+// preference: quality, then specificity, then header order
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, order: i})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		si, sj := specificity(ranges[i].mediaType), specificity(ranges[j].mediaType)
+		if si != sj {
+			return si > sj
+		}
+		return ranges[i].order < ranges[j].order
+	})
+	return ranges
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// mediaTypeMatches reports whether a media type matches a range, which may
+// contain type/* or */* wildcards
+func mediaTypeMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+// NegotiateMediaType returns the offered media type best matching an Accept
+// header. An empty header accepts the first offer.
+func NegotiateMediaType(accept string, offered ...string) (string, bool) {
+	if len(offered) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offered[0], true
+	}
+
+	for _, r := range parseAccept(accept) {
+		if r.q <= 0 {
+			continue
+		}
+		for _, offer := range offered {
+			if mediaTypeMatches(r.mediaType, offer) && !explicitlyRefused(accept, offer) {
+				return offer, true
+			}
+		}
+	}
+	return "", false
+}
+
+// explicitlyRefused reports whether the Accept header lists the media type
+// itself with q=0
+func explicitlyRefused(accept, mediaType string) bool {
+	for _, r := range parseAccept(accept) {
+		if r.mediaType == mediaType && r.q <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds a token to the response's Vary header unless it is already
+// listed, keeping the tokens other middleware added
+func addVary(h http.Header, token string) {
+	for _, v := range h.Values("Vary") {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t == "*" || strings.EqualFold(t, token) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", token)
+}
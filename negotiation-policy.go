@@ -0,0 +1,88 @@
+package responseutils
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NegotiationPolicy controls what happens when the Accept header cannot be satisfied
+type NegotiationPolicy int32
+
+const (
+	// NegotiationFallback sends the default representation (JSON for envelopes)
+	// with a Warning header; this is the default
+	NegotiationFallback NegotiationPolicy = iota
+	// NegotiationStrict sends the standard 406 error envelope
+	NegotiationStrict
+)
+
+// negotiationWarning is sent when falling back to a representation the client did not accept
+const negotiationWarning = `299 - "Accept header not satisfiable; sent %s"`
+
+var negotiationPolicy atomic.Int32
+
+// SetNegotiationPolicy sets the policy applied when content negotiation fails
+func SetNegotiationPolicy(p NegotiationPolicy) {
+	negotiationPolicy.Store(int32(p))
+}
+
+// CurrentNegotiationPolicy returns the policy applied when content negotiation fails
+func CurrentNegotiationPolicy() NegotiationPolicy {
+	return NegotiationPolicy(negotiationPolicy.Load())
+}
+
+// String returns the name of the policy
+func (p NegotiationPolicy) String() string {
+	if p == NegotiationStrict {
+		return "strict"
+	}
+	return "fallback"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p NegotiationPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// negotiate returns the offered media type best matching the request's
+// Accept header. When none matches, the fallback policy sends the first offer
+// with a Warning header, while the strict policy reports false so the caller
+// sends a 406.
+func negotiate(c *gin.Context, offered ...string) (string, bool) {
+	accept := ""
+	if c.Request != nil {
+		accept = c.GetHeader("Accept")
+	}
+	if mediaType, ok := NegotiateMediaType(accept, offered...); ok {
+		return mediaType, true
+	}
+	if len(offered) == 0 || CurrentNegotiationPolicy() == NegotiationStrict {
+		return "", false
+	}
+
+	c.Header("Warning", strings.Replace(negotiationWarning, "%s", offered[0], 1))
+	return offered[0], true
+}
+
+// envelopeAcceptable applies the negotiation policy to a JSON envelope about
+// to be written with the given content type, reporting false when a 406 must
+// be sent instead
+func envelopeAcceptable(c *gin.Context, contentType string) bool {
+	mediaType := contentType
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+
+	offered := []string{mediaType}
+	if mediaType != "application/json" {
+		// problem+json and other JSON types are acceptable to JSON clients
+		offered = append(offered, "application/json")
+	}
+	if _, encrypted := c.Get(encryptionKey); encrypted {
+		offered = append(offered, JOSEContentType)
+	}
+	_, ok := negotiate(c, offered...)
+	return ok
+}
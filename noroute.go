@@ -0,0 +1,108 @@
+package responseutils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRouteSuggestions is the maximum number of routes suggested in did_you_mean
+const maxRouteSuggestions = 3
+
+// NoRouteHandler returns a handler for gin's NoRoute that sends the standard
+// 404 envelope. When debug mode is on and an engine is given, near-miss
+// routes are suggested in details.did_you_mean.
+func NoRouteHandler(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := NotFound("Route")
+
+		if engine != nil && DebugEnabled() {
+			if suggestions := SuggestRoutes(engine, c.Request.URL.Path); len(suggestions) > 0 {
+				err = err.WithDetails("did_you_mean", suggestions)
+			}
+		}
+
+		ErrorResponse(c, err)
+		c.Abort()
+	}
+}
+
+// SuggestRoutes returns the registered route patterns closest to a request
+// path by Levenshtein distance. Parameter segments match any value.
+func SuggestRoutes(engine *gin.Engine, path string) []string {
+	type candidate struct {
+		route    string
+		distance int
+	}
+
+	threshold := len(path) / 5
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	seen := map[string]bool{}
+	var candidates []candidate
+	for _, route := range engine.Routes() {
+		if seen[route.Path] {
+			continue
+		}
+		seen[route.Path] = true
+
+		d := levenshtein(fillParams(route.Path, path), path)
+		if d <= threshold {
+			candidates = append(candidates, candidate{route.Path, d})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].route < candidates[j].route
+	})
+
+	suggestions := make([]string, 0, maxRouteSuggestions)
+	for i := 0; i < len(candidates) && i < maxRouteSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].route)
+	}
+	return suggestions
+}
+
+// fillParams replaces :param and *wildcard segments of a route pattern with
+// the request path's segment at the same position
+func fillParams(pattern, path string) string {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+
+	for i, part := range patternParts {
+		if i < len(pathParts) && (strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*")) {
+			patternParts[i] = pathParts[i]
+		}
+	}
+	return strings.Join(patternParts, "/")
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,87 @@
+package responseutils
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+const parentResourceKeyPrefix = "responseutils.parent."
+
+// ParentFetcher loads a parent resource by the ID in its path parameter. It
+// returns a nil value, or an error matching ErrNotFound, when the parent does
+// not exist. Parents resolved earlier in the chain are available through
+// ParentResource, so nested lookups can be scoped to them.
+type ParentFetcher func(c *gin.Context, id string) (interface{}, error)
+
+// RequireParentResource resolves the parent resource named by the param path
+// parameter, e.g. the user of /users/:user_id/orders, and stores it for
+// ParentResource. When the parent does not exist it answers with the
+// standard missing parent response, following the empty list policy for
+// GET and HEAD requests, aborts the chain and returns false; other fetch
+// errors are sent with ErrorResponse.
+func RequireParentResource(c *gin.Context, resource, param string, fetch ParentFetcher) (interface{}, bool) {
+	id := c.Param(param)
+	var parent interface{}
+	var err error
+	if id != "" {
+		parent, err = fetch(c, id)
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, ErrNotFound):
+		ErrorResponse(c, err)
+	case id == "" || err != nil || isNilValue(parent):
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			MissingParentResponse(c, resource)
+		} else {
+			ErrorResponse(c, ParentNotFound(resource))
+		}
+	default:
+		c.Set(parentResourceKeyPrefix+resource, parent)
+		return parent, true
+	}
+	c.Abort()
+	return nil, false
+}
+
+// ParentResourceMiddleware returns middleware calling RequireParentResource
+// before the handler, for routes sharing a parent:
+//
+//	users := r.Group("/users/:user_id", responseutils.ParentResourceMiddleware("User", "user_id", loadUser))
+//	users.GET("/orders", listOrders)
+func ParentResourceMiddleware(resource, param string, fetch ParentFetcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := RequireParentResource(c, resource, param, fetch); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ParentResource returns the parent resource resolved by
+// RequireParentResource for resource
+func ParentResource[T any](c *gin.Context, resource string) (T, bool) {
+	v, ok := c.Get(parentResourceKeyPrefix + resource)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	parent, ok := v.(T)
+	return parent, ok
+}
+
+// isNilValue reports whether v is nil or a nil pointer, map or slice
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package responseutils
+
+import "net/http"
+
+// Password policy rules
+const (
+	PasswordRuleMinLength = "min_length"
+	PasswordRuleMaxLength = "max_length"
+	PasswordRuleUppercase = "uppercase"
+	PasswordRuleLowercase = "lowercase"
+	PasswordRuleDigit     = "digit"
+	PasswordRuleSymbol    = "symbol"
+	PasswordRuleReuse     = "reuse"
+	PasswordRuleBreached  = "breached"
+	PasswordRuleCommon    = "common"
+)
+
+// PolicyViolation describes a password rule the submitted password failed
+// @Description Password policy violation structure
+type PolicyViolation struct {
+	Rule    string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"must be at least 12 characters"`
+	// Limit is the rule's threshold, e.g. the minimum length or the
+	// number of previous passwords that cannot be reused
+	Limit int `json:"limit,omitempty" example:"12"`
+}
+
+// PasswordPolicyError creates a 400 error enumerating the failed password
+// rules under details.violations, so UIs can render specific guidance
+func PasswordPolicyError(violations []PolicyViolation) *ResponseError {
+	if violations == nil {
+		violations = []PolicyViolation{}
+	}
+
+	return NewResponseError(
+		ErrCodePasswordPolicy,
+		"Password does not meet the password policy",
+		http.StatusBadRequest,
+	).WithDetails("violations", violations)
+}
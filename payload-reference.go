@@ -0,0 +1,172 @@
+package responseutils
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const payloadReferenceKey = "responseutils.payload_reference"
+
+// DefaultReferenceThreshold is the serialized data size above which
+// ReferenceLargePayloads stores the data as a blob
+const DefaultReferenceThreshold = 1 << 20
+
+// BlobStore stores payloads too large to send inline and returns where
+// clients can download them, e.g. a presigned object store URL
+type BlobStore interface {
+	Put(c *gin.Context, data []byte, contentType string) (href string, expiresAt time.Time, err error)
+}
+
+// BlobStoreFunc adapts a function to the BlobStore interface
+type BlobStoreFunc func(c *gin.Context, data []byte, contentType string) (string, time.Time, error)
+
+// Put implements BlobStore
+func (f BlobStoreFunc) Put(c *gin.Context, data []byte, contentType string) (string, time.Time, error) {
+	return f(c, data, contentType)
+}
+
+// ReferenceOptions configure ReferenceLargePayloads
+type ReferenceOptions struct {
+	// Threshold is the serialized data size in bytes above which the data is
+	// sent by reference; defaults to DefaultReferenceThreshold
+	Threshold int
+	// StatusCode replaces the status of referenced responses, e.g.
+	// http.StatusAccepted; 0 keeps the handler's status
+	StatusCode int
+}
+
+// PayloadReference replaces data that was stored as a blob
+// @Description Reference to response data stored for download
+type PayloadReference struct {
+	Href        string    `json:"href" example:"/blobs/0b6f6c3e-3c1a-4c55-9e8d-4df1f5b2b1e2"`
+	ContentType string    `json:"content_type" example:"application/json"`
+	Size        int       `json:"size" example:"5242880"`
+	Digest      string    `json:"digest" example:"sha-256=:lkcDOQq4mndYXoRtcqawQss5pXpcE1YmY6WxJBAv3xE=:"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+}
+
+type payloadReference struct {
+	store BlobStore
+	opts  ReferenceOptions
+}
+
+// ReferenceLargePayloads returns middleware sending the data of a route's
+// success responses by reference when it serializes to more than
+// opts.Threshold bytes: the data is stored in store and replaced by a
+// PayloadReference, keeping bodies within API gateway size limits
+func ReferenceLargePayloads(store BlobStore, opts ReferenceOptions) gin.HandlerFunc {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultReferenceThreshold
+	}
+
+	return func(c *gin.Context) {
+		c.Set(payloadReferenceKey, payloadReference{store: store, opts: opts})
+		c.Next()
+	}
+}
+
+// applyPayloadReference stores the data of a success envelope over the
+// route's threshold, replacing it by its reference with meta.payload set to
+// "reference" and a "payload" link
+func applyPayloadReference(c *gin.Context, e *Envelope) error {
+	v, ok := c.Get(payloadReferenceKey)
+	if !ok || !e.Success() {
+		return nil
+	}
+	ref := v.(payloadReference)
+
+	data := e.Data()
+	if data == nil {
+		return nil
+	}
+	encoded, err := CurrentEncoder().Marshal(data)
+	if err != nil {
+		return err
+	}
+	if len(encoded) <= ref.opts.Threshold {
+		return nil
+	}
+
+	href, expiresAt, err := ref.store.Put(c, encoded, "application/json")
+	if err != nil {
+		return err
+	}
+	e.SetData(PayloadReference{
+		Href:        href,
+		ContentType: "application/json",
+		Size:        len(encoded),
+		Digest:      ContentDigest(encoded),
+		ExpiresAt:   expiresAt,
+	})
+	e.SetMeta("payload", "reference")
+	e.AddLink("payload", href)
+	if ref.opts.StatusCode != 0 {
+		e.StatusCode = ref.opts.StatusCode
+	}
+	return nil
+}
+
+// MemoryBlobStore keeps referenced payloads in memory for ttl, for
+// development and single-instance deployments. Serve them with Handler.
+type MemoryBlobStore struct {
+	basePath string
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	blobs map[string]memoryBlob
+}
+
+type memoryBlob struct {
+	data        []byte
+	contentType string
+	expiresAt   time.Time
+}
+
+// NewMemoryBlobStore creates a store whose blobs are served under basePath,
+// e.g. "/blobs"; a ttl of 0 keeps them forever
+func NewMemoryBlobStore(basePath string, ttl time.Duration) *MemoryBlobStore {
+	return &MemoryBlobStore{basePath: strings.TrimRight(basePath, "/"), ttl: ttl, blobs: map[string]memoryBlob{}}
+}
+
+// Put implements BlobStore
+func (s *MemoryBlobStore) Put(_ *gin.Context, data []byte, contentType string) (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, b := range s.blobs {
+		if !b.expiresAt.IsZero() && now.After(b.expiresAt) {
+			delete(s.blobs, id)
+		}
+	}
+
+	var expiresAt time.Time
+	if s.ttl > 0 {
+		expiresAt = now.Add(s.ttl).UTC()
+	}
+	id := uuid.NewString()
+	s.blobs[id] = memoryBlob{data: data, contentType: contentType, expiresAt: expiresAt}
+	return s.basePath + "/" + id, expiresAt, nil
+}
+
+// Handler serves the blob named by the "id" path parameter; register it as
+// GET basePath + "/:id"
+func (s *MemoryBlobStore) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.mu.Lock()
+		b, ok := s.blobs[c.Param("id")]
+		s.mu.Unlock()
+
+		if !ok || (!b.expiresAt.IsZero() && time.Now().After(b.expiresAt)) {
+			ErrorResponse(c, NotFound("Payload"))
+			return
+		}
+		c.Header("Content-Digest", ContentDigest(b.data))
+		c.Data(http.StatusOK, b.contentType, b.data)
+	}
+}
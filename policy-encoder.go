@@ -0,0 +1,808 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TimeFormat controls how time.Time values are serialized
+type TimeFormat int
+
+const (
+	// TimeRFC3339 serializes times as RFC 3339 strings (the encoding/json default)
+	TimeRFC3339 TimeFormat = iota
+	// TimeEpochMillis serializes times as milliseconds since the Unix epoch
+	TimeEpochMillis
+)
+
+// NumberFormat controls whether numbers are serialized as JSON numbers or strings
+type NumberFormat int
+
+const (
+	// NumberAsNumber serializes values as JSON numbers
+	NumberAsNumber NumberFormat = iota
+	// NumberAsString serializes values as JSON strings
+	NumberAsString
+	// NumberAsStringWhenUnsafe serializes integers outside the range JavaScript
+	// can represent exactly (±2^53-1) as strings and all others as numbers
+	NumberAsStringWhenUnsafe
+)
+
+// MaxSafeInteger is the largest integer JavaScript numbers represent exactly
+const MaxSafeInteger = 1<<53 - 1
+
+// CollectionFormat controls how nil and empty slices and maps are serialized
+type CollectionFormat int
+
+const (
+	// CollectionsAsIs serializes nil collections as null and empty ones as []/{} (the encoding/json default)
+	CollectionsAsIs CollectionFormat = iota
+	// NilAsEmpty serializes nil slices as [] and nil maps as {}
+	NilAsEmpty
+	// EmptyAsNull serializes nil and empty slices and maps as null
+	EmptyAsNull
+)
+
+// KeyCasing controls how object keys inside Data are serialized
+type KeyCasing int
+
+const (
+	// KeysAsIs keeps keys as declared by struct tags and map keys
+	KeysAsIs KeyCasing = iota
+	// KeysCamelCase converts keys to camelCase
+	KeysCamelCase
+	// KeysSnakeCase converts keys to snake_case
+	KeysSnakeCase
+)
+
+// EncodingPolicy describes envelope-wide serialization rules
+type EncodingPolicy struct {
+	// TimeFormat applies to time.Time values
+	TimeFormat TimeFormat `json:"time_format"`
+	// Int64Format applies to int64 and uint64 values. NumberAsStringWhenUnsafe
+	// applies to every integer kind and to integral json.Number values.
+	Int64Format NumberFormat `json:"int64_format"`
+	// DecimalFormat applies to float32, float64 and json.Number values
+	DecimalFormat NumberFormat `json:"decimal_format"`
+	// Collections applies to slices and maps; byte slices are unaffected
+	Collections CollectionFormat `json:"collections"`
+	// KeyCasing applies to object keys within the envelope's data member.
+	// Output of json.Marshaler implementations is left untouched, and keys
+	// cased to another key of their object fail the encoding.
+	KeyCasing KeyCasing `json:"key_casing"`
+}
+
+var (
+	timeFormatNames       = []string{"rfc3339", "epoch_millis"}
+	numberFormatNames     = []string{"number", "string", "string_when_unsafe"}
+	collectionFormatNames = []string{"as_is", "nil_as_empty", "empty_as_null"}
+	keyCasingNames        = []string{"as_is", "camel_case", "snake_case"}
+)
+
+// String returns the name of the format
+func (f TimeFormat) String() string { return enumName(timeFormatNames, int(f)) }
+
+// MarshalText implements encoding.TextMarshaler
+func (f TimeFormat) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+
+// String returns the name of the format
+func (f NumberFormat) String() string { return enumName(numberFormatNames, int(f)) }
+
+// MarshalText implements encoding.TextMarshaler
+func (f NumberFormat) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+
+// String returns the name of the format
+func (f CollectionFormat) String() string { return enumName(collectionFormatNames, int(f)) }
+
+// MarshalText implements encoding.TextMarshaler
+func (f CollectionFormat) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+
+// String returns the name of the casing
+func (k KeyCasing) String() string { return enumName(keyCasingNames, int(k)) }
+
+// MarshalText implements encoding.TextMarshaler
+func (k KeyCasing) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+func enumName(names []string, i int) string {
+	if i < 0 || i >= len(names) {
+		return strconv.Itoa(i)
+	}
+	return names[i]
+}
+
+// PolicyEncoder is an Encoder applying an EncodingPolicy to every value it
+// serializes, so services emit consistent numbers and dates without editing
+// model tags. It honours json struct tags and json.Marshaler implementations.
+type PolicyEncoder struct {
+	policy EncodingPolicy
+}
+
+// NewPolicyEncoder creates an encoder applying the given policy
+func NewPolicyEncoder(policy EncodingPolicy) *PolicyEncoder {
+	return &PolicyEncoder{policy: policy}
+}
+
+// Policy returns the policy applied by the encoder
+func (e *PolicyEncoder) Policy() EncodingPolicy {
+	return e.policy
+}
+
+// Marshal implements Encoder
+func (e *PolicyEncoder) Marshal(v interface{}) ([]byte, error) {
+	s := &policyEncodeState{policy: e.policy}
+	if err := s.encode(reflect.ValueOf(v), false); err != nil {
+		return nil, err
+	}
+	return s.buf.Bytes(), nil
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	numberType        = reflect.TypeOf(json.Number(""))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// startDetectingCyclesAfter is the pointer depth past which the encoder
+// tracks the values it is inside of, as encoding/json does
+const startDetectingCyclesAfter = 1000
+
+type policyEncodeState struct {
+	buf      bytes.Buffer
+	policy   EncodingPolicy
+	depth    int
+	inData   bool
+	ptrLevel uint
+	ptrSeen  map[interface{}]struct{}
+}
+
+// encode writes v; quoted requests the ",string" struct tag behaviour
+func (s *policyEncodeState) encode(v reflect.Value, quoted bool) error {
+	if !v.IsValid() {
+		s.buf.WriteString("null")
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		if elem := v.Type().Elem(); elem == timeType || elem == numberType {
+			v = v.Elem()
+		}
+	}
+
+	if v.Type() == timeType && s.policy.TimeFormat == TimeEpochMillis {
+		s.buf.WriteString(strconv.FormatInt(v.Interface().(time.Time).UnixMilli(), 10))
+		return nil
+	}
+
+	if v.Type() == numberType {
+		return s.encodeNumber(v.String())
+	}
+
+	if handled, err := s.encodeMarshaler(v); handled {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		s.writeMaybeQuoted(strconv.FormatBool(v.Bool()), quoted)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		s.writeMaybeQuoted(strconv.FormatInt(n, 10), quoted || s.quoteInt(v.Kind(), n > MaxSafeInteger || n < -MaxSafeInteger))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := v.Uint()
+		s.writeMaybeQuoted(strconv.FormatUint(n, 10), quoted || s.quoteInt(v.Kind(), n > MaxSafeInteger))
+	case reflect.Float32, reflect.Float64:
+		f, err := formatFloat(v.Float(), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		s.writeMaybeQuoted(f, quoted || s.policy.DecimalFormat == NumberAsString)
+	case reflect.String:
+		if quoted {
+			str, err := json.Marshal(v.String())
+			if err != nil {
+				return err
+			}
+			return s.writeString(string(str))
+		}
+		return s.writeString(v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			s.buf.WriteString("null")
+			return nil
+		}
+		return s.encode(v.Elem(), quoted)
+	case reflect.Pointer:
+		if v.IsNil() {
+			s.buf.WriteString("null")
+			return nil
+		}
+		ptr := v.UnsafePointer()
+		if err := s.enter(v, ptr); err != nil {
+			return err
+		}
+		defer s.leave(ptr)
+		return s.encode(v.Elem(), quoted)
+	case reflect.Struct:
+		return s.encodeStruct(v)
+	case reflect.Map:
+		if !v.IsNil() {
+			ptr := v.UnsafePointer()
+			if err := s.enter(v, ptr); err != nil {
+				return err
+			}
+			defer s.leave(ptr)
+		}
+		return s.encodeMap(v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.IsNil() {
+				s.buf.WriteString("null")
+				return nil
+			}
+			return s.writeString(base64.StdEncoding.EncodeToString(v.Bytes()))
+		}
+		if s.writeEmptyCollection(v, "[]") {
+			return nil
+		}
+		// a slice is identified by its start and length, as subslices sharing
+		// the array are not cycles
+		ptr := struct {
+			ptr uintptr
+			len int
+		}{uintptr(v.UnsafePointer()), v.Len()}
+		if err := s.enter(v, ptr); err != nil {
+			return err
+		}
+		defer s.leave(ptr)
+		return s.encodeArray(v)
+	case reflect.Array:
+		return s.encodeArray(v)
+	default:
+		return &json.UnsupportedTypeError{Type: v.Type()}
+	}
+
+	return nil
+}
+
+// enter records that the encoder is inside of the value identified by ptr,
+// failing once the value contains itself
+func (s *policyEncodeState) enter(v reflect.Value, ptr interface{}) error {
+	s.ptrLevel++
+	if s.ptrLevel <= startDetectingCyclesAfter {
+		return nil
+	}
+	if s.ptrSeen == nil {
+		s.ptrSeen = make(map[interface{}]struct{})
+	}
+	if _, ok := s.ptrSeen[ptr]; ok {
+		return &json.UnsupportedValueError{Value: v, Str: fmt.Sprintf("encountered a cycle via %s", v.Type())}
+	}
+	s.ptrSeen[ptr] = struct{}{}
+	return nil
+}
+
+// leave undoes enter once the value is written
+func (s *policyEncodeState) leave(ptr interface{}) {
+	if s.ptrLevel > startDetectingCyclesAfter {
+		delete(s.ptrSeen, ptr)
+	}
+	s.ptrLevel--
+}
+
+// encodeMarshaler writes values implementing json.Marshaler or encoding.TextMarshaler
+func (s *policyEncodeState) encodeMarshaler(v reflect.Value) (bool, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return false, nil
+	}
+
+	mv := v
+	if !mv.Type().Implements(marshalerType) && !mv.Type().Implements(textMarshalerType) {
+		if !mv.CanAddr() {
+			return false, nil
+		}
+		mv = mv.Addr()
+	}
+
+	if m, ok := mv.Interface().(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return true, err
+		}
+		if err := json.Compact(&s.buf, data); err != nil {
+			return true, fmt.Errorf("json: error calling MarshalJSON for type %s: %w", mv.Type(), err)
+		}
+		return true, nil
+	}
+
+	if m, ok := mv.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return true, err
+		}
+		return true, s.writeString(string(text))
+	}
+
+	return false, nil
+}
+
+func (s *policyEncodeState) encodeNumber(n string) error {
+	if n == "" {
+		n = "0"
+	}
+	if !json.Valid([]byte(n)) {
+		return fmt.Errorf("json: invalid number literal %q", n)
+	}
+	if s.policy.Int64Format == NumberAsStringWhenUnsafe {
+		if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+			s.writeMaybeQuoted(n, i > MaxSafeInteger || i < -MaxSafeInteger)
+			return nil
+		}
+		if !strings.ContainsAny(n, ".eE") {
+			// an integer too large for int64 is necessarily unsafe
+			s.writeMaybeQuoted(n, true)
+			return nil
+		}
+	}
+	s.writeMaybeQuoted(n, s.policy.DecimalFormat == NumberAsString)
+	return nil
+}
+
+// quoteInt reports whether an integer of the given kind is serialized as a string
+func (s *policyEncodeState) quoteInt(kind reflect.Kind, unsafe bool) bool {
+	switch s.policy.Int64Format {
+	case NumberAsString:
+		return kind == reflect.Int64 || kind == reflect.Uint64
+	case NumberAsStringWhenUnsafe:
+		return unsafe
+	}
+	return false
+}
+
+func (s *policyEncodeState) encodeStruct(v reflect.Value) error {
+	s.buf.WriteByte('{')
+	keys := s.objectKeys()
+	first := true
+	for _, f := range cachedFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if f.omitZero && isZeroValue(fv) {
+			continue
+		}
+
+		if !first {
+			s.buf.WriteByte(',')
+		}
+		first = false
+
+		if err := s.encodeMember(keys, f.name, fv, f.quoted); err != nil {
+			return err
+		}
+	}
+	s.buf.WriteByte('}')
+	return nil
+}
+
+func (s *policyEncodeState) encodeMap(v reflect.Value) error {
+	if s.writeEmptyCollection(v, "{}") {
+		return nil
+	}
+
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+	members := make([]member, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		members = append(members, member{key: key, value: iter.Value()})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+
+	s.buf.WriteByte('{')
+	keys := s.objectKeys()
+	for i, m := range members {
+		if i > 0 {
+			s.buf.WriteByte(',')
+		}
+		if err := s.encodeMember(keys, m.key, m.value, false); err != nil {
+			return err
+		}
+	}
+	s.buf.WriteByte('}')
+	return nil
+}
+
+// objectKeys returns the set an object's keys are recorded in while key
+// casing applies, mapping each written key to the key it was cased from
+func (s *policyEncodeState) objectKeys() map[string]string {
+	if !s.inData {
+		return nil
+	}
+	return make(map[string]string)
+}
+
+// encodeMember writes an object member, applying key casing within Data.
+// Keys cased to a key already written to the object are an error, as the
+// object would hold the key twice.
+func (s *policyEncodeState) encodeMember(keys map[string]string, key string, v reflect.Value, quoted bool) error {
+	if s.inData {
+		original := key
+		key = transformKey(key, s.policy.KeyCasing)
+		if other, ok := keys[key]; ok {
+			return fmt.Errorf("json: keys %q and %q both serialize as %q with %s keys", other, original, key, s.policy.KeyCasing)
+		}
+		keys[key] = original
+	}
+	if err := s.writeString(key); err != nil {
+		return err
+	}
+	s.buf.WriteByte(':')
+
+	enteredData := s.depth == 0 && key == "data" && s.policy.KeyCasing != KeysAsIs
+	if enteredData {
+		s.inData = true
+	}
+	s.depth++
+	err := s.encode(v, quoted)
+	s.depth--
+	if enteredData {
+		s.inData = false
+	}
+	return err
+}
+
+// writeEmptyCollection writes nil or empty slices and maps according to the
+// collection policy, reporting whether anything was written
+func (s *policyEncodeState) writeEmptyCollection(v reflect.Value, empty string) bool {
+	switch {
+	case v.IsNil() && s.policy.Collections == NilAsEmpty:
+		s.buf.WriteString(empty)
+	case v.IsNil(), v.Len() == 0 && s.policy.Collections == EmptyAsNull:
+		s.buf.WriteString("null")
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *policyEncodeState) encodeArray(v reflect.Value) error {
+	s.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			s.buf.WriteByte(',')
+		}
+		if err := s.encode(v.Index(i), false); err != nil {
+			return err
+		}
+	}
+	s.buf.WriteByte(']')
+	return nil
+}
+
+func (s *policyEncodeState) writeString(str string) error {
+	data, err := json.Marshal(str)
+	if err != nil {
+		return err
+	}
+	s.buf.Write(data)
+	return nil
+}
+
+func (s *policyEncodeState) writeMaybeQuoted(literal string, quoted bool) {
+	if quoted {
+		s.buf.WriteByte('"')
+		s.buf.WriteString(literal)
+		s.buf.WriteByte('"')
+		return
+	}
+	s.buf.WriteString(literal)
+}
+
+// formatFloat formats a float the same way encoding/json does
+func formatFloat(f float64, bits int) (string, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	str := strconv.FormatFloat(f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(str); n >= 4 && str[n-4] == 'e' && str[n-3] == '-' && str[n-2] == '0' {
+			str = str[:n-2] + str[n-1:]
+		}
+	}
+	return str, nil
+}
+
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+func isZeroValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	return v.IsZero()
+}
+
+// fieldByIndex walks an embedded field path, reporting false for nil embedded pointers
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+type encodeField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+	tagged    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]encodeField
+
+// cachedFields returns the serialized fields of a struct type following
+// encoding/json rules for tags and embedded structs
+func cachedFields(t reflect.Type) []encodeField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]encodeField)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]encodeField)
+}
+
+func typeFields(t reflect.Type) []encodeField {
+	type candidate struct {
+		encodeField
+		depth int
+	}
+
+	var candidates []candidate
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []queued{}
+	next := []queued{{typ: t}}
+	visited := map[reflect.Type]bool{}
+
+	for depth := 0; len(next) > 0; depth++ {
+		current, next = next, current[:0]
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			visited[q.typ] = true
+
+			for i := 0; i < q.typ.NumField(); i++ {
+				sf := q.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+
+				index := make([]int, len(q.index)+1)
+				copy(index, q.index)
+				index[len(q.index)] = i
+
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, queued{typ: ft, index: index})
+					continue
+				}
+
+				field := encodeField{
+					name:      name,
+					index:     index,
+					omitEmpty: hasTagOption(opts, "omitempty"),
+					omitZero:  hasTagOption(opts, "omitzero"),
+					tagged:    name != "",
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+				if hasTagOption(opts, "string") {
+					switch ft.Kind() {
+					case reflect.Bool,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64, reflect.String:
+						field.quoted = true
+					}
+				}
+				candidates = append(candidates, candidate{encodeField: field, depth: depth})
+			}
+		}
+	}
+
+	// Resolve name conflicts: the shallowest field wins, and a tagged field
+	// beats untagged ones at the same depth. Ambiguous fields are dropped.
+	byName := map[string][]candidate{}
+	var order []string
+	for _, c := range candidates {
+		if _, ok := byName[c.name]; !ok {
+			order = append(order, c.name)
+		}
+		byName[c.name] = append(byName[c.name], c)
+	}
+
+	var fields []encodeField
+	for _, name := range order {
+		group := byName[name]
+		best := group[0]
+		ambiguous := false
+		for _, c := range group[1:] {
+			switch {
+			case c.depth < best.depth || c.depth == best.depth && c.tagged && !best.tagged:
+				best, ambiguous = c, false
+			case c.depth == best.depth && c.tagged == best.tagged:
+				ambiguous = true
+			}
+		}
+		if !ambiguous {
+			fields = append(fields, best.encodeField)
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+	return fields
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// transformKey converts a key to the given casing
+func transformKey(key string, casing KeyCasing) string {
+	if casing == KeysAsIs || key == "" {
+		return key
+	}
+
+	words := splitWords(key)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+
+	if casing == KeysSnakeCase {
+		return strings.Join(words, "_")
+	}
+
+	for i := 1; i < len(words); i++ {
+		r, size := utf8.DecodeRuneInString(words[i])
+		words[i] = string(unicode.ToUpper(r)) + words[i][size:]
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits snake_case, kebab-case, camelCase and PascalCase keys
+// into words, keeping acronyms such as "ID" or "HTTP" together
+func splitWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := 0
+
+	flush := func(end int) {
+		if end > start {
+			words = append(words, string(runes[start:end]))
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				flush(i)
+				start = i
+			}
+		}
+	}
+	flush(len(runes))
+
+	return words
+}
@@ -0,0 +1,25 @@
+package responseutils
+
+import "testing"
+
+func TestTransformKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		casing KeyCasing
+		want   string
+	}{
+		{"user_id", KeysCamelCase, "userId"},
+		{"UserID", KeysSnakeCase, "user_id"},
+		{"HTTPStatus", KeysCamelCase, "httpStatus"},
+		{"created-at", KeysCamelCase, "createdAt"},
+		{"userId", KeysAsIs, "userId"},
+		{"foo_été", KeysCamelCase, "fooÉté"},
+		{"größe_maß", KeysCamelCase, "größeMaß"},
+		{"fooÉté", KeysSnakeCase, "foo_été"},
+	}
+	for _, tt := range tests {
+		if got := transformKey(tt.key, tt.casing); got != tt.want {
+			t.Errorf("transformKey(%q, %s) = %q, want %q", tt.key, tt.casing, got, tt.want)
+		}
+	}
+}
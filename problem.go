@@ -0,0 +1,52 @@
+package responseutils
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// ProblemDetails represents an RFC 7807 problem details error response
+// @Description RFC 7807 problem details structure
+type ProblemDetails struct {
+	Type     string  `json:"type" example:"about:blank"`
+	Title    string  `json:"title" example:"Not Found"`
+	Status   int     `json:"status" example:"404"`
+	Detail   string  `json:"detail,omitempty" example:"User not found"`
+	Instance string  `json:"instance,omitempty" example:"/users/123"`
+	Code     string  `json:"code" example:"NOT_FOUND"`
+	Details  Details `json:"details,omitempty"`
+	// Reference is the encrypted error reference of 5xx problems, see
+	// SetErrorReferenceKey
+	Reference string `json:"reference,omitempty" example:"ref1_q83vEjRWeJq8..."`
+}
+
+// NewProblemDetails converts an error into RFC 7807 problem details
+func NewProblemDetails(err error, instance string) ProblemDetails {
+	statusCode, body := errorBody(err)
+	problem := ProblemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Instance: instance,
+	}
+	problem.Code, _ = body["code"].(string)
+	problem.Detail, _ = body["message"].(string)
+	problem.Details, _ = body["details"].(Details)
+	return problem
+}
+
+// ProblemResponse sends an error as RFC 7807 problem details, releasing
+// errors from AcquireResponseError once written
+func ProblemResponse(c *gin.Context, err error) {
+	instance := ""
+	if c.Request != nil {
+		instance = c.Request.URL.Path
+	}
+	problem := NewProblemDetails(err, instance)
+	applyErrorHeaders(c, err)
+	writeTyped(c, problem.Status, problemContentType, problem)
+	releaseWrittenError(c, err)
+}
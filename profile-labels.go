@@ -0,0 +1,54 @@
+package responseutils
+
+import (
+	"runtime/pprof"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const profileLabelsKey = "responseutils.profile_labels"
+
+// ProfileLabels returns middleware tagging the goroutine handling each
+// request with pprof labels, so CPU and goroutine profiles can be sliced by
+// endpoint and outcome: "route" ("GET /orders/:id") while the handler runs,
+// plus "status" and "error_code" once the response pipeline knows them. The
+// goroutine's previous labels are restored when the request ends.
+//
+//	go tool pprof -tagfocus=route='GET /orders/:id' cpu.pprof
+func ProfileLabels() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orig := c.Request.Context()
+		defer pprof.SetGoroutineLabels(orig)
+
+		c.Set(profileLabelsKey, true)
+		SetProfileLabel(c, "route", c.Request.Method+" "+c.FullPath())
+		c.Next()
+	}
+}
+
+// SetProfileLabel adds a pprof label to the goroutine handling the request,
+// e.g. a tenant or query kind, on ProfileLabels routes
+func SetProfileLabel(c *gin.Context, key, value string) {
+	if !c.GetBool(profileLabelsKey) {
+		return
+	}
+	ctx := pprof.WithLabels(c.Request.Context(), pprof.Labels(key, value))
+	c.Request = c.Request.WithContext(ctx)
+	pprof.SetGoroutineLabels(ctx)
+}
+
+// applyProfileLabels labels the serialization and writing of an envelope
+// with its status and error code
+func applyProfileLabels(c *gin.Context, e *Envelope) {
+	if !c.GetBool(profileLabelsKey) {
+		return
+	}
+	code := e.ErrorCode()
+	if code == "" {
+		code = "none"
+	}
+	ctx := pprof.WithLabels(c.Request.Context(), pprof.Labels("status", strconv.Itoa(e.StatusCode), "error_code", code))
+	c.Request = c.Request.WithContext(ctx)
+	pprof.SetGoroutineLabels(ctx)
+}
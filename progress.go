@@ -0,0 +1,109 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskState is the lifecycle state of a background task
+type TaskState string
+
+const (
+	TaskQueued    TaskState = "queued"
+	TaskRunning   TaskState = "running"
+	TaskSucceeded TaskState = "succeeded"
+	TaskFailed    TaskState = "failed"
+	TaskCancelled TaskState = "cancelled"
+)
+
+// Finished reports whether a task in this state has stopped for good
+func (s TaskState) Finished() bool {
+	return s == TaskSucceeded || s == TaskFailed || s == TaskCancelled
+}
+
+// TaskProgress is the standard progress payload of a background task
+// @Description Background task progress structure
+type TaskProgress struct {
+	ID          string    `json:"id" example:"job_8f14e45f"`
+	State       TaskState `json:"state" example:"running"`
+	Percent     int       `json:"percent" example:"40"`
+	Stage       string    `json:"stage,omitempty" example:"rendering"`
+	ETA         time.Time `json:"eta,omitzero"`
+	Cancellable bool      `json:"cancellable" example:"true"`
+	StartedAt   time.Time `json:"started_at,omitzero"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+}
+
+// ProgressReporter is a background task reporting its progress
+type ProgressReporter interface {
+	Progress() TaskProgress
+}
+
+// CancellableTask is a background task that can be asked to stop
+type CancellableTask interface {
+	ProgressReporter
+	// Cancel requests cancellation; the task may take a while to stop
+	Cancel() error
+}
+
+func TaskAlreadyFinished(id string, state TaskState) *ResponseError {
+	return NewResponseError(ErrCodeTaskAlreadyFinished,
+		fmt.Sprintf("Task '%s' has already finished", id), http.StatusConflict).
+		WithDetails("id", id).
+		WithDetails("state", state)
+}
+
+func TaskNotCancellable(id, stage string) *ResponseError {
+	err := NewResponseError(ErrCodeTaskNotCancellable,
+		fmt.Sprintf("Task '%s' cannot be cancelled at its current stage", id), http.StatusConflict).
+		WithDetails("id", id)
+	if stage != "" {
+		err = err.WithDetails("stage", stage)
+	}
+	return err
+}
+
+// ProgressResponse sends a 200 OK response with a task's progress. When the
+// task reports no ETA one is extrapolated from its start time and percent.
+func ProgressResponse(c *gin.Context, task ProgressReporter) {
+	OKResponse(c, normalizeProgress(task.Progress(), time.Now()), "")
+}
+
+// CancelTaskResponse cancels a task and sends a 202 Accepted response with its
+// progress, or a 409 Conflict when the task has already finished or cannot be
+// cancelled at its current stage
+func CancelTaskResponse(c *gin.Context, task CancellableTask) {
+	p := task.Progress()
+	if p.State.Finished() {
+		ErrorResponse(c, TaskAlreadyFinished(p.ID, p.State))
+		return
+	}
+	if !p.Cancellable {
+		ErrorResponse(c, TaskNotCancellable(p.ID, p.Stage))
+		return
+	}
+	if err := task.Cancel(); err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+	AcceptedResponse(c, normalizeProgress(task.Progress(), time.Now()), "")
+}
+
+// normalizeProgress clamps the percent, reports finished tasks as neither
+// cancellable nor pending, and estimates a missing ETA linearly
+func normalizeProgress(p TaskProgress, now time.Time) TaskProgress {
+	p.Percent = min(max(p.Percent, 0), 100)
+	if p.State.Finished() {
+		p.Cancellable = false
+		p.ETA = time.Time{}
+		return p
+	}
+	if p.ETA.IsZero() && !p.StartedAt.IsZero() && p.Percent > 0 && p.Percent < 100 {
+		elapsed := now.Sub(p.StartedAt)
+		p.ETA = now.Add(elapsed * time.Duration(100-p.Percent) / time.Duration(p.Percent)).UTC().Truncate(time.Second)
+	}
+	return p
+}
@@ -0,0 +1,107 @@
+package responseutils
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	pendingEventKey  = "responseutils.pending_event"
+	eventResourceKey = "responseutils.event_resource"
+)
+
+// Operation is the kind of write a domain event describes
+type Operation string
+
+const (
+	OperationCreated Operation = "created"
+	OperationUpdated Operation = "updated"
+	OperationDeleted Operation = "deleted"
+)
+
+// DomainEvent describes a successful write, published after the response
+// @Description Domain event structure
+type DomainEvent struct {
+	ResourceType string      `json:"resource_type" example:"user"`
+	ResourceID   string      `json:"resource_id" example:"123"`
+	Operation    Operation   `json:"operation" example:"created"`
+	Payload      interface{} `json:"payload,omitempty" swaggertype:"object"`
+	OccurredAt   time.Time   `json:"occurred_at"`
+}
+
+// Publisher publishes domain events, e.g. to Kafka, NATS or an outbox table
+type Publisher interface {
+	Publish(ctx context.Context, event DomainEvent) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, event DomainEvent) error
+
+// Publish implements Publisher
+func (f PublisherFunc) Publish(ctx context.Context, event DomainEvent) error {
+	return f(ctx, event)
+}
+
+type eventResource struct {
+	resourceType string
+	id           string
+}
+
+// PublishEvents returns middleware publishing a domain event after each
+// successful 2xx CreatedResponse or UpdatedResponse, with the response data
+// as the payload. Handlers name the resource with SetEventResource, otherwise
+// the route path and its :id parameter are used. Publish failures cannot
+// change the response already sent; they are added to c.Errors.
+func PublishEvents(pub Publisher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		v, ok := c.Get(pendingEventKey)
+		if !ok {
+			return
+		}
+		event := v.(DomainEvent)
+		if status := c.Writer.Status(); status < 200 || status > 299 {
+			return
+		}
+
+		res := eventResource{resourceType: c.FullPath(), id: c.Param("id")}
+		if r, ok := c.Get(eventResourceKey); ok {
+			res = r.(eventResource)
+		}
+		event.ResourceType = res.resourceType
+		event.ResourceID = res.id
+
+		if err := pub.Publish(c.Request.Context(), event); err != nil {
+			debugPrintf("publishing %s %s event failed: %v", event.ResourceType, event.Operation, err)
+			c.Error(err)
+		}
+	}
+}
+
+// SetEventResource names the resource of the domain event published for this request
+func SetEventResource(c *gin.Context, resourceType, id string) {
+	c.Set(eventResourceKey, eventResource{resourceType: resourceType, id: id})
+}
+
+// PublishEvent records a domain event to be published by PublishEvents once
+// the response is written, for writes not sent with CreatedResponse or
+// UpdatedResponse such as deletes
+func PublishEvent(c *gin.Context, op Operation, payload interface{}) {
+	c.Set(pendingEventKey, DomainEvent{
+		Operation:  op,
+		Payload:    payload,
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+// recordWriteEvent records the domain event for a write response unless the
+// handler already recorded one with PublishEvent
+func recordWriteEvent(c *gin.Context, op Operation, payload interface{}) {
+	if _, ok := c.Get(pendingEventKey); ok {
+		return
+	}
+	PublishEvent(c, op, payload)
+}
@@ -0,0 +1,330 @@
+package responseutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Redacted replaces redacted header and field values in recordings
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedHeaders are always redacted from recordings
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// RecordedExchange is a recorded request and the full response sent for it
+type RecordedExchange struct {
+	StartedAt      time.Time     `json:"started_at"`
+	Duration       time.Duration `json:"duration"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	Proto          string        `json:"proto"`
+	RequestHeader  http.Header   `json:"request_header"`
+	RequestBody    []byte        `json:"request_body,omitempty"`
+	StatusCode     int           `json:"status_code"`
+	ResponseHeader http.Header   `json:"response_header"`
+	ResponseBody   []byte        `json:"response_body,omitempty"`
+	Truncated      bool          `json:"truncated,omitempty"`
+}
+
+// RecordingSink stores recorded exchanges, e.g. in a file, bucket or queue
+type RecordingSink interface {
+	Record(ctx context.Context, x *RecordedExchange) error
+}
+
+// RecordingSinkFunc adapts a function to the RecordingSink interface
+type RecordingSinkFunc func(ctx context.Context, x *RecordedExchange) error
+
+// Record implements RecordingSink
+func (f RecordingSinkFunc) Record(ctx context.Context, x *RecordedExchange) error {
+	return f(ctx, x)
+}
+
+// RingSink keeps the most recent recordings in memory
+type RingSink struct {
+	mu      sync.Mutex
+	entries []*RecordedExchange
+	next    int
+	full    bool
+}
+
+// NewRingSink creates a sink keeping the last size recordings
+func NewRingSink(size int) *RingSink {
+	if size < 1 {
+		size = 1
+	}
+	return &RingSink{entries: make([]*RecordedExchange, size)}
+}
+
+// Record implements RecordingSink
+func (s *RingSink) Record(_ context.Context, x *RecordedExchange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[s.next] = x
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Recordings returns the kept recordings, oldest first
+func (s *RingSink) Recordings() []*RecordedExchange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]*RecordedExchange(nil), s.entries[:s.next]...)
+	}
+	return append(append([]*RecordedExchange(nil), s.entries[s.next:]...), s.entries[:s.next]...)
+}
+
+// RecordingOptions control which exchanges are recorded and what is redacted
+type RecordingOptions struct {
+	// SampleRate is the fraction (0-1) of requests recorded
+	SampleRate float64
+	// Sample, when set, decides per request instead of SampleRate
+	Sample func(c *gin.Context) bool
+	// RedactHeaders are redacted in addition to DefaultRedactedHeaders
+	RedactHeaders []string
+	// RedactFields are JSON object keys redacted at any depth of the bodies,
+	// and form and query parameters redacted by name. When set, bodies that
+	// are neither JSON nor forms are not recorded.
+	RedactFields []string
+	// MaxBodyBytes truncates recorded bodies; 0 means 64KiB
+	MaxBodyBytes int
+}
+
+// RecordResponses returns middleware recording sampled requests and their
+// full responses to sink, after redaction. Sink failures are added to c.Errors.
+func RecordResponses(sink RecordingSink, opts RecordingOptions) gin.HandlerFunc {
+	limit := opts.MaxBodyBytes
+	if limit <= 0 {
+		limit = 64 << 10
+	}
+	redactHeaders := append(append([]string(nil), DefaultRedactedHeaders...), opts.RedactHeaders...)
+
+	return func(c *gin.Context) {
+		if opts.Sample != nil && !opts.Sample(c) || opts.Sample == nil && rand.Float64() >= opts.SampleRate {
+			c.Next()
+			return
+		}
+
+		x := &RecordedExchange{
+			StartedAt:     time.Now(),
+			Method:        c.Request.Method,
+			URL:           requestURL(c.Request),
+			Proto:         c.Request.Proto,
+			RequestHeader: c.Request.Header.Clone(),
+		}
+
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+			if err == nil {
+				x.RequestBody, x.Truncated = truncate(body, limit)
+			}
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = w
+		c.Next()
+
+		x.Duration = time.Since(x.StartedAt)
+		x.StatusCode = w.Status()
+		x.ResponseHeader = w.Header().Clone()
+		x.ResponseBody = w.body.Bytes()
+		x.Truncated = x.Truncated || w.truncated
+
+		redactExchange(x, redactHeaders, opts.RedactFields)
+		if err := sink.Record(c.Request.Context(), x); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// recordingWriter copies the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) capture(b []byte) {
+	if room := w.limit - w.body.Len(); room < len(b) {
+		b = b[:max(room, 0)]
+		w.truncated = true
+	}
+	w.body.Write(b)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func truncate(b []byte, limit int) ([]byte, bool) {
+	if len(b) > limit {
+		return b[:limit], true
+	}
+	return b, false
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// redactExchange redacts headers, query parameters and body fields in place.
+// Bodies that cannot be parsed are dropped when fields are redacted, as
+// nothing in them could be redacted.
+func redactExchange(x *RecordedExchange, headers, fields []string) {
+	for _, h := range headers {
+		for _, hdr := range []http.Header{x.RequestHeader, x.ResponseHeader} {
+			if vs := hdr.Values(h); len(vs) > 0 {
+				hdr.Set(h, Redacted)
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+	x.URL = redactURL(x.URL, fields)
+	if x.Truncated {
+		x.RequestBody, x.ResponseBody = nil, nil
+		return
+	}
+	x.RequestBody = redactBody(x.RequestBody, x.RequestHeader.Get("Content-Type"), fields)
+	x.ResponseBody = redactBody(x.ResponseBody, x.ResponseHeader.Get("Content-Type"), fields)
+}
+
+// redactURL redacts the named query parameters, dropping a query that cannot
+// be parsed
+func redactURL(raw string, fields []string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if u.RawQuery == "" {
+		return raw
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		u.RawQuery = ""
+		return u.String()
+	}
+	if !redactForm(query, fields) {
+		return raw
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactBody redacts the named fields of a form or JSON body, returning nil
+// for other bodies
+func redactBody(body []byte, contentType string, fields []string) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		if !redactForm(form, fields) {
+			return body
+		}
+		return []byte(form.Encode())
+	}
+
+	if !json.Valid(body) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+
+	if !redactValue(v, fields) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// redactForm replaces the values of the named keys, reporting whether any
+// were found
+func redactForm(form url.Values, fields []string) bool {
+	found := false
+	for k, vs := range form {
+		if containsFold(fields, k) {
+			for i := range vs {
+				vs[i] = Redacted
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// redactValue replaces the named keys at any depth, reporting whether any were found
+func redactValue(v interface{}, fields []string) bool {
+	found := false
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if containsFold(fields, k) {
+				node[k] = Redacted
+				found = true
+				continue
+			}
+			found = redactValue(child, fields) || found
+		}
+	case []interface{}:
+		for _, child := range node {
+			found = redactValue(child, fields) || found
+		}
+	}
+	return found
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
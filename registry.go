@@ -0,0 +1,168 @@
+package responseutils
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorCodeInfo describes a registered error code
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	StatusCode  int    `json:"status_code"`
+	Description string `json:"description"`
+}
+
+var builtinErrorCodes = []ErrorCodeInfo{
+	{ErrCodeBadRequest, http.StatusBadRequest, "Generic bad request"},
+	{ErrCodeUnauthorized, http.StatusUnauthorized, "Authentication required"},
+	{ErrCodeForbidden, http.StatusForbidden, "Access denied"},
+	{ErrCodeNotFound, http.StatusNotFound, "Resource not found"},
+	{ErrCodeConflict, http.StatusConflict, "Resource conflict"},
+	{ErrCodeValidation, http.StatusBadRequest, "Input validation failed"},
+	{ErrCodeInternalServer, http.StatusInternalServerError, "Server error"},
+	{ErrCodeDatabase, http.StatusInternalServerError, "Database operation failed"},
+	{ErrCodeInvalidInput, http.StatusBadRequest, "Invalid field input"},
+	{ErrCodeMissingHeader, http.StatusBadRequest, "Required header missing"},
+	{ErrCodeInvalidUUID, http.StatusBadRequest, "Invalid UUID format"},
+	{ErrCodeDuplicateEntry, http.StatusConflict, "Duplicate resource"},
+	{ErrCodeForeignKeyViolation, http.StatusBadRequest, "Foreign key constraint violation"},
+	{ErrCodeInvalidBody, http.StatusBadRequest, "Invalid request body"},
+	{ErrUserAccountLocked, http.StatusForbidden, "User account locked"},
+	{ErrUnauthorizedError, http.StatusUnauthorized, "Unauthorized"},
+	{ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "HTTP method not allowed for the route"},
+	{ErrCodeUnsupportedMediaType, http.StatusUnsupportedMediaType, "Request content type not supported"},
+	{ErrCodeNotAcceptable, http.StatusNotAcceptable, "No acceptable response representation"},
+	{ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge, "Request body exceeds the size limit"},
+	{ErrCodeInvalidMultipart, http.StatusBadRequest, "Malformed multipart request"},
+	{ErrCodeMissingFile, http.StatusBadRequest, "Required file missing from upload"},
+	{ErrCodeUploadFailed, http.StatusBadRequest, "No uploaded file could be processed"},
+	{ErrCodeInvalidImage, http.StatusBadRequest, "Image could not be decoded"},
+	{ErrCodeInvalidCloudEvent, http.StatusBadRequest, "Event does not conform to CloudEvents 1.0"},
+	{ErrCodeUnavailableForLegalReasons, http.StatusUnavailableForLegalReasons, "Blocked for legal reasons"},
+	{ErrCodePaymentRequired, http.StatusPaymentRequired, "Payment or plan upgrade required"},
+	{ErrCodeInsufficientScope, http.StatusForbidden, "Access token lacks required scopes"},
+	{ErrCodeAccountSuspended, http.StatusForbidden, "User account suspended"},
+	{ErrCodeEmailUnverified, http.StatusForbidden, "Email address not verified"},
+	{ErrCodePasswordExpired, http.StatusForbidden, "Password must be changed"},
+	{ErrCodeMFARequired, http.StatusUnauthorized, "Second authentication factor required"},
+	{ErrCodePasswordPolicy, http.StatusBadRequest, "Password fails policy rules"},
+	{ErrCodeChallengeRequired, http.StatusForbidden, "Captcha or bot challenge required"},
+	{ErrCodeRegionRestricted, http.StatusUnavailableForLegalReasons, "Service not available in the client's region"},
+	{ErrCodeInvalidEnumValue, http.StatusBadRequest, "Value not in the permitted set"},
+	{ErrCodeOutOfRange, http.StatusBadRequest, "Number outside the permitted range"},
+	{ErrCodeInvalidDateRange, http.StatusBadRequest, "Date range start is after its end"},
+	{ErrCodeInvalidULID, http.StatusBadRequest, "Invalid ULID format"},
+	{ErrCodeInvalidID, http.StatusBadRequest, "Invalid ID format"},
+	{ErrCodeInvalidSyncToken, http.StatusBadRequest, "Sync token malformed or tampered with"},
+	{ErrCodeSyncTokenExpired, http.StatusGone, "Sync token expired; full resync required"},
+	{ErrCodeResourceGone, http.StatusGone, "Resource was deleted"},
+	{ErrCodeInvalidStateTransition, http.StatusConflict, "Resource cannot move to the requested state"},
+	{ErrCodeInvalidExpansion, http.StatusBadRequest, "Unknown or too deep expand= relation"},
+	{ErrCodeUnsupportedVersion, http.StatusNotAcceptable, "Requested representation version not supported"},
+	{ErrCodeUnknownEncryptionKey, http.StatusBadRequest, "Response encryption key id not registered"},
+	{ErrCodeTaskAlreadyFinished, http.StatusConflict, "Background task has already finished"},
+	{ErrCodeTaskNotCancellable, http.StatusConflict, "Background task cannot be cancelled at its current stage"},
+	{ErrCodeInvalidConsistencyToken, http.StatusBadRequest, "Consistency token malformed"},
+	{ErrCodeServiceOverloaded, http.StatusServiceUnavailable, "Request shed while the service is overloaded"},
+	{ErrCodeConcurrencyLimit, http.StatusTooManyRequests, "Too many concurrent requests from the client"},
+	{ErrCodeShuttingDown, http.StatusServiceUnavailable, "Instance is draining for shutdown"},
+	{ErrCodeInvalidSearchCursor, http.StatusBadRequest, "Search cursor malformed"},
+	{ErrCodeInvalidGeometry, http.StatusBadRequest, "GeoJSON geometry malformed"},
+	{ErrCodeInvalidBBox, http.StatusBadRequest, "Bounding box malformed or out of range"},
+	{ErrCodeInvalidAmount, http.StatusBadRequest, "Monetary amount or currency malformed"},
+	{ErrCodeCurrencyMismatch, http.StatusUnprocessableEntity, "Amounts in different currencies combined"},
+	{ErrCodeMissingAPIKey, http.StatusUnauthorized, "API key not sent"},
+	{ErrCodeMalformedAPIKey, http.StatusUnauthorized, "API key not in the expected format"},
+	{ErrCodeInvalidAPIKey, http.StatusUnauthorized, "API key unknown or secret wrong"},
+	{ErrCodeRevokedAPIKey, http.StatusUnauthorized, "API key revoked"},
+	{ErrCodeExpiredAPIKey, http.StatusUnauthorized, "API key expired"},
+	{ErrCodeMissingWebhookSignature, http.StatusUnauthorized, "Webhook signature or timestamp not sent"},
+	{ErrCodeInvalidWebhookSignature, http.StatusUnauthorized, "Webhook signature does not match"},
+	{ErrCodeWebhookSignatureExpired, http.StatusUnauthorized, "Webhook timestamp outside the tolerance"},
+	{ErrCodeRequestReplayed, http.StatusConflict, "Request or nonce already processed"},
+}
+
+var (
+	registryMu   sync.RWMutex
+	codeRegistry = func() map[string]ErrorCodeInfo {
+		m := make(map[string]ErrorCodeInfo, len(builtinErrorCodes))
+		for _, info := range builtinErrorCodes {
+			m[info.Code] = info
+		}
+		return m
+	}()
+)
+
+// RegisterErrorCode registers a service-specific error code, replacing any
+// existing registration for the same code
+func RegisterErrorCode(code string, statusCode int, description string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	codeRegistry[code] = ErrorCodeInfo{Code: code, StatusCode: statusCode, Description: description}
+}
+
+// LookupErrorCode returns the registration of an error code
+func LookupErrorCode(code string) (ErrorCodeInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := codeRegistry[code]
+	return info, ok
+}
+
+// ErrorCodes returns every registered error code, sorted by code
+func ErrorCodes() []ErrorCodeInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	codes := make([]ErrorCodeInfo, 0, len(codeRegistry))
+	for _, info := range codeRegistry {
+		codes = append(codes, info)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// ErrorForStatus returns an error for a bare status code with the generic
+// code for it, e.g. NOT_FOUND for 404, or BAD_REQUEST and
+// INTERNAL_SERVER_ERROR for 4xx and 5xx statuses without one. An empty
+// message defaults to the status text.
+func ErrorForStatus(statusCode int, message string) *ResponseError {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return NewResponseError(statusErrorCode(statusCode), message, statusCode)
+}
+
+func statusErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusPaymentRequired:
+		return ErrCodePaymentRequired
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrCodeMethodNotAllowed
+	case http.StatusNotAcceptable:
+		return ErrCodeNotAcceptable
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusGone:
+		return ErrCodeResourceGone
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrCodeUnsupportedMediaType
+	case http.StatusUnavailableForLegalReasons:
+		return ErrCodeUnavailableForLegalReasons
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return ErrCodeInternalServer
+	}
+	return ErrCodeBadRequest
+}
@@ -0,0 +1,209 @@
+// Package render mimics the call signatures of go-chi/render, and of echo's
+// context methods, on top of the standard envelope, so a service can
+// migrate to response-utils by swapping an import path before rewriting its
+// handlers:
+//
+//	import "github.com/geekible-ltd/response-utils/render" // was github.com/go-chi/render
+//
+//	render.Status(r, http.StatusCreated)
+//	render.JSON(w, r, order) // {"success":true,"data":{...}}
+//
+// Values are sent as the data of a success envelope and errors with
+// responseutils.ErrorResponse. Handlers run outside gin, so the response
+// hooks, defaults and features that rely on gin middleware only apply to
+// what is set on the request context, e.g. by ResponseDefaultsMiddleware.
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+	"github.com/gin-gonic/gin"
+)
+
+type contextKey struct{ name string }
+
+// StatusCtxKey is the request context key of the status set by Status
+var StatusCtxKey = &contextKey{"Status"}
+
+// Renderer prepares a value before it is rendered, e.g. filling derived
+// fields, as in go-chi/render
+type Renderer interface {
+	Render(w http.ResponseWriter, r *http.Request) error
+}
+
+// Binder post-processes a decoded request body, as in go-chi/render
+type Binder interface {
+	Bind(r *http.Request) error
+}
+
+// Respond is the responder used by Render and RenderList; it defaults to
+// DefaultResponder and may be replaced, as in go-chi/render
+var Respond = DefaultResponder
+
+// writerCtxKey carries the envelope writer serve runs to the engine
+var writerCtxKey = &contextKey{"Writer"}
+
+// engine serves the requests handed over by serve. It has no routes, so
+// every request reaches the handler running the writer; it is created on
+// first use.
+var engine = sync.OnceValue(func() *gin.Engine {
+	e := gin.New()
+	e.NoRoute(func(c *gin.Context) {
+		write := c.Request.Context().Value(writerCtxKey).(func(c *gin.Context))
+		write(c)
+	})
+	return e
+})
+
+// Status sets the status code Respond and JSON send
+func Status(r *http.Request, status int) {
+	*r = *r.WithContext(context.WithValue(r.Context(), StatusCtxKey, status))
+}
+
+// DefaultResponder sends v in the standard envelope: errors as error
+// envelopes, nil with 204 No Content if the status set is 204, and other
+// values as the data of a success envelope. A value sent with a 4xx or 5xx
+// status becomes an error with the generic code of the status.
+func DefaultResponder(w http.ResponseWriter, r *http.Request, v interface{}) {
+	status, _ := r.Context().Value(StatusCtxKey).(int)
+	serve(w, r, func(c *gin.Context) { respond(c, status, v) })
+}
+
+// JSON sends v in the standard envelope, see DefaultResponder
+func JSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	DefaultResponder(w, r, v)
+}
+
+// NoContent sends 204 No Content
+func NoContent(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, responseutils.NoContentResponse)
+}
+
+// Render calls v's Render method and responds with v
+func Render(w http.ResponseWriter, r *http.Request, v Renderer) error {
+	if err := v.Render(w, r); err != nil {
+		return err
+	}
+	Respond(w, r, v)
+	return nil
+}
+
+// RenderList calls each element's Render method and responds with the list
+func RenderList(w http.ResponseWriter, r *http.Request, l []Renderer) error {
+	for _, v := range l {
+		if err := v.Render(w, r); err != nil {
+			return err
+		}
+	}
+	if l == nil {
+		l = []Renderer{}
+	}
+	Respond(w, r, l)
+	return nil
+}
+
+// Bind decodes a JSON request body into v and calls its Bind method. Decode
+// errors are INVALID_BODY errors, ready to be sent with JSON.
+func Bind(r *http.Request, v Binder) error {
+	if err := DecodeJSON(r.Body, v); err != nil {
+		return err
+	}
+	return v.Bind(r)
+}
+
+// DecodeJSON decodes a JSON body into v, see Bind
+func DecodeJSON(body io.Reader, v interface{}) error {
+	defer io.Copy(io.Discard, body)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return responseutils.BindingError(err)
+	}
+	return nil
+}
+
+// serve runs an envelope writer for a net/http handler in a gin context of
+// the request
+func serve(w http.ResponseWriter, r *http.Request, write func(c *gin.Context)) {
+	engine().ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), writerCtxKey, write)))
+}
+
+func respond(c *gin.Context, status int, v interface{}) {
+	if err, ok := v.(error); ok {
+		var appErr *responseutils.ResponseError
+		if status >= http.StatusBadRequest && !errors.As(err, &appErr) {
+			err = responseutils.ErrorForStatus(status, "").WithDetails("error", err.Error())
+		}
+		responseutils.ErrorResponse(c, err)
+		return
+	}
+
+	switch {
+	case status == 0:
+		status = http.StatusOK
+	case status == http.StatusNoContent:
+		responseutils.NoContentResponse(c)
+		return
+	case status >= http.StatusBadRequest:
+		err := responseutils.ErrorForStatus(status, "")
+		if msg, ok := v.(string); ok {
+			err = responseutils.ErrorForStatus(status, msg)
+		} else if v != nil {
+			err = err.WithDetails("error", v)
+		}
+		responseutils.ErrorResponse(c, err)
+		return
+	}
+	responseutils.SuccessResponse(c, status, v, "")
+}
+
+// Context mimics the response methods of echo's Context, so echo handler
+// bodies keep their c.JSON(http.StatusOK, v) calls
+type Context struct {
+	w http.ResponseWriter
+	r *http.Request
+}
+
+// NewContext returns an echo-style context for a request
+func NewContext(w http.ResponseWriter, r *http.Request) *Context {
+	return &Context{w: w, r: r}
+}
+
+// Request returns the request
+func (c *Context) Request() *http.Request {
+	return c.r
+}
+
+// JSON sends i with the status code, see DefaultResponder
+func (c *Context) JSON(code int, i interface{}) error {
+	serve(c.w, c.r, func(gc *gin.Context) { respond(gc, code, i) })
+	return nil
+}
+
+// NoContent sends the status code without a body
+func (c *Context) NoContent(code int) error {
+	if code < http.StatusBadRequest {
+		c.w.WriteHeader(code)
+		return nil
+	}
+	return c.JSON(code, nil)
+}
+
+// String sends s as the message of a success envelope, or of an error for
+// 4xx and 5xx status codes
+func (c *Context) String(code int, s string) error {
+	if code >= http.StatusBadRequest {
+		return c.JSON(code, s)
+	}
+	serve(c.w, c.r, func(gc *gin.Context) { responseutils.SuccessResponse(gc, code, nil, s) })
+	return nil
+}
+
+// Error sends err as an error envelope, like echo's HTTPErrorHandler
+func (c *Context) Error(err error) {
+	serve(c.w, c.r, func(gc *gin.Context) { responseutils.ErrorResponse(gc, err) })
+}
@@ -0,0 +1,133 @@
+package responseutils
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NonceHeader is the default header carrying a request nonce
+const NonceHeader = "X-Nonce"
+
+// DefaultNonceTTL is how long nonces are remembered by default
+const DefaultNonceTTL = 24 * time.Hour
+
+// NonceStore remembers nonces for a while, so a request seen once is
+// rejected when it is sent again
+type NonceStore interface {
+	// Remember records key for ttl, returning false when it was already
+	// recorded and has not expired
+	Remember(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// NonceStoreFunc adapts a function to the NonceStore interface
+type NonceStoreFunc func(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+// Remember implements NonceStore
+func (f NonceStoreFunc) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return f(ctx, key, ttl)
+}
+
+// MemoryNonceStore is a NonceStore for a single instance; use a shared
+// store such as Redis (SET NX with an expiry) behind several instances
+type MemoryNonceStore struct {
+	mu    sync.Mutex
+	keys  map[string]time.Time
+	sweep time.Time
+}
+
+// NewMemoryNonceStore creates an empty in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{keys: map[string]time.Time{}}
+}
+
+// Remember implements NonceStore
+func (m *MemoryNonceStore) Remember(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.After(m.sweep) {
+		for k, expires := range m.keys {
+			if now.After(expires) {
+				delete(m.keys, k)
+			}
+		}
+		m.sweep = now.Add(time.Minute)
+	}
+
+	if expires, ok := m.keys[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	m.keys[key] = now.Add(ttl)
+	return true, nil
+}
+
+// CheckNonce records a nonce in store for ttl, returning a 409
+// REQUEST_REPLAYED error when it has been seen before. scope keeps nonces
+// of different senders or purposes apart, e.g. a payment provider's name.
+func CheckNonce(ctx context.Context, store NonceStore, scope, nonce string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = DefaultNonceTTL
+	}
+	fresh, err := store.Remember(ctx, "nonce:"+scope+":"+nonce, ttl)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return RequestReplayed().WithDetails("nonce", nonce)
+	}
+	return nil
+}
+
+// NonceOptions configure RequireNonce
+type NonceOptions struct {
+	// Header carrying the nonce; defaults to X-Nonce
+	Header string
+	// TTL is how long nonces are remembered; defaults to DefaultNonceTTL
+	TTL time.Duration
+	// Scope returns the scope nonces are unique within, e.g. the API key or
+	// tenant; defaults to a single scope for all requests
+	Scope func(c *gin.Context) string
+}
+
+// RequireNonce returns middleware rejecting requests without a nonce with
+// 400 MISSING_HEADER and requests repeating a nonce with 409
+// REQUEST_REPLAYED, for callbacks that must be processed at most once
+func RequireNonce(store NonceStore, opts NonceOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = NonceHeader
+	}
+
+	return func(c *gin.Context) {
+		nonce := c.GetHeader(opts.Header)
+		if nonce == "" {
+			ErrorResponse(c, MissingHeader(opts.Header))
+			c.Abort()
+			return
+		}
+		if len(nonce) > 256 {
+			ErrorResponse(c, InvalidInput(opts.Header, "must be at most 256 characters"))
+			c.Abort()
+			return
+		}
+
+		scope := ""
+		if opts.Scope != nil {
+			scope = opts.Scope(c)
+		}
+		if err := CheckNonce(c.Request.Context(), store, scope, nonce, opts.TTL); err != nil {
+			ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func RequestReplayed() *ResponseError {
+	return NewResponseError(ErrCodeRequestReplayed, "This request has already been processed", http.StatusConflict)
+}
@@ -0,0 +1,166 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionedMediaType matches media types such as application/vnd.company.v2+json
+var versionedMediaType = regexp.MustCompile(`^application/vnd\.([a-z0-9][a-z0-9.-]*?)\.v([0-9]+)\+json$`)
+
+var (
+	vendorMu sync.RWMutex
+	vendor   string
+)
+
+// SetMediaTypeVendor sets the vendor expected in versioned media types, e.g.
+// "company" for application/vnd.company.v2+json. When unset any vendor is accepted.
+func SetMediaTypeVendor(name string) {
+	vendorMu.Lock()
+	defer vendorMu.Unlock()
+	vendor = strings.ToLower(name)
+}
+
+// RequestedVersion returns the representation version requested by the
+// Accept header, reporting false when no versioned media type was requested
+func RequestedVersion(c *gin.Context) (int, bool) {
+	version, _, ok := requestedRepresentation(c)
+	return version, ok
+}
+
+// requestedRepresentation returns the most preferred versioned media type of
+// the Accept header and its version
+func requestedRepresentation(c *gin.Context) (int, string, bool) {
+	if c.Request == nil {
+		return 0, "", false
+	}
+	vendorMu.RLock()
+	want := vendor
+	vendorMu.RUnlock()
+
+	for _, r := range parseAccept(c.GetHeader("Accept")) {
+		if r.q <= 0 {
+			continue
+		}
+		m := versionedMediaType.FindStringSubmatch(r.mediaType)
+		if m == nil || want != "" && m[1] != want {
+			continue
+		}
+		version, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		return version, r.mediaType, true
+	}
+	return 0, "", false
+}
+
+// Representations are the serializers of a resource's representation
+// versions, selected per request by the Accept header
+type Representations[T any] struct {
+	resource       string
+	defaultVersion int
+	serializers    map[int]func(T) interface{}
+}
+
+// NewRepresentations creates the representations of a resource. Requests
+// without a versioned media type get defaultVersion.
+func NewRepresentations[T any](resource string, defaultVersion int) *Representations[T] {
+	return &Representations[T]{
+		resource:       resource,
+		defaultVersion: defaultVersion,
+		serializers:    map[int]func(T) interface{}{},
+	}
+}
+
+// Register sets the serializer of a representation version
+func (r *Representations[T]) Register(version int, serialize func(T) interface{}) *Representations[T] {
+	r.serializers[version] = serialize
+	return r
+}
+
+// Versions returns the registered versions in ascending order
+func (r *Representations[T]) Versions() []int {
+	versions := make([]int, 0, len(r.serializers))
+	for v := range r.serializers {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// serializer returns the serializer and content type for the request
+func (r *Representations[T]) serializer(c *gin.Context) (func(T) interface{}, string, *ResponseError) {
+	version, mediaType, requested := requestedRepresentation(c)
+	contentType := jsonContentType
+	if requested {
+		contentType = mediaType
+	} else {
+		version = r.defaultVersion
+	}
+
+	serialize, ok := r.serializers[version]
+	if !ok {
+		return nil, "", UnsupportedVersion(r.resource, version, r.Versions())
+	}
+	return serialize, contentType, nil
+}
+
+func UnsupportedVersion(resource string, version int, supported []int) *ResponseError {
+	if supported == nil {
+		supported = []int{}
+	}
+	return NewResponseError(
+		ErrCodeUnsupportedVersion,
+		fmt.Sprintf("Version %d of the %s representation is not supported", version, resource),
+		http.StatusNotAcceptable,
+	).WithDetails("version", version).
+		WithDetails("supported", supported)
+}
+
+// VersionedResponse sends a success response serialized by the representation
+// version the client requested. Versioned requests get the versioned media type
+// back as Content-Type; unsupported versions get a 406 UNSUPPORTED_VERSION
+// error listing the supported ones.
+func VersionedResponse[T any](c *gin.Context, statusCode int, r *Representations[T], v T, message string) {
+	addVary(c.Writer.Header(), "Accept")
+	serialize, contentType, err := r.serializer(c)
+	if err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	writeTyped(c, statusCode, contentType, Response{
+		Success: true,
+		Data:    serialize(v),
+		Message: message,
+	})
+}
+
+// VersionedListResponse sends a paginated list with each item serialized by
+// the requested representation version, see VersionedResponse
+func VersionedListResponse[T any](c *gin.Context, r *Representations[T], items []T, pagination *Pagination) {
+	addVary(c.Writer.Header(), "Accept")
+	serialize, contentType, err := r.serializer(c)
+	if err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	data := make([]interface{}, len(items))
+	for i, item := range items {
+		data[i] = serialize(item)
+	}
+	writeTyped(c, http.StatusOK, contentType, ListResponse{
+		Success:    true,
+		Data:       data,
+		Pagination: pagination,
+	})
+}
@@ -7,22 +7,93 @@ import (
 
 // Error codes
 const (
-	ErrCodeBadRequest          = "BAD_REQUEST"
-	ErrCodeUnauthorized        = "UNAUTHORIZED"
-	ErrCodeForbidden           = "FORBIDDEN"
-	ErrCodeNotFound            = "NOT_FOUND"
-	ErrCodeConflict            = "CONFLICT"
-	ErrCodeValidation          = "VALIDATION_ERROR"
-	ErrCodeInternalServer      = "INTERNAL_SERVER_ERROR"
-	ErrCodeDatabase            = "DATABASE_ERROR"
-	ErrCodeInvalidInput        = "INVALID_INPUT"
-	ErrCodeMissingHeader       = "MISSING_HEADER"
-	ErrCodeInvalidUUID         = "INVALID_UUID"
-	ErrCodeDuplicateEntry      = "DUPLICATE_ENTRY"
-	ErrCodeForeignKeyViolation = "FOREIGN_KEY_VIOLATION"
-	ErrCodeInvalidBody         = "INVALID_BODY"
-	ErrUserAccountLocked       = "ACCOUNT_LOCKED"
-	ErrUnauthorizedError       = "UNAUTHORIZED_ERROR"
+	ErrCodeBadRequest                 = "BAD_REQUEST"
+	ErrCodeUnauthorized               = "UNAUTHORIZED"
+	ErrCodeForbidden                  = "FORBIDDEN"
+	ErrCodeNotFound                   = "NOT_FOUND"
+	ErrCodeConflict                   = "CONFLICT"
+	ErrCodeValidation                 = "VALIDATION_ERROR"
+	ErrCodeInternalServer             = "INTERNAL_SERVER_ERROR"
+	ErrCodeDatabase                   = "DATABASE_ERROR"
+	ErrCodeInvalidInput               = "INVALID_INPUT"
+	ErrCodeMissingHeader              = "MISSING_HEADER"
+	ErrCodeInvalidUUID                = "INVALID_UUID"
+	ErrCodeDuplicateEntry             = "DUPLICATE_ENTRY"
+	ErrCodeForeignKeyViolation        = "FOREIGN_KEY_VIOLATION"
+	ErrCodeInvalidBody                = "INVALID_BODY"
+	ErrUserAccountLocked              = "ACCOUNT_LOCKED"
+	ErrUnauthorizedError              = "UNAUTHORIZED_ERROR"
+	ErrCodeRequestReplayed            = "REQUEST_REPLAYED"
+	ErrCodeWebhookSignatureExpired    = "WEBHOOK_SIGNATURE_EXPIRED"
+	ErrCodeInvalidWebhookSignature    = "INVALID_WEBHOOK_SIGNATURE"
+	ErrCodeMissingWebhookSignature    = "MISSING_WEBHOOK_SIGNATURE"
+	ErrCodeExpiredAPIKey              = "EXPIRED_API_KEY"
+	ErrCodeRevokedAPIKey              = "REVOKED_API_KEY"
+	ErrCodeInvalidAPIKey              = "INVALID_API_KEY"
+	ErrCodeMalformedAPIKey            = "MALFORMED_API_KEY"
+	ErrCodeMissingAPIKey              = "MISSING_API_KEY"
+	ErrCodeCurrencyMismatch           = "CURRENCY_MISMATCH"
+	ErrCodeInvalidAmount              = "INVALID_AMOUNT"
+	ErrCodeInvalidBBox                = "INVALID_BBOX"
+	ErrCodeInvalidGeometry            = "INVALID_GEOMETRY"
+	ErrCodeInvalidSearchCursor        = "INVALID_SEARCH_CURSOR"
+	ErrCodeShuttingDown               = "SHUTTING_DOWN"
+	ErrCodeConcurrencyLimit           = "CONCURRENCY_LIMIT_EXCEEDED"
+	ErrCodeServiceOverloaded          = "SERVICE_OVERLOADED"
+	ErrCodeInvalidConsistencyToken    = "INVALID_CONSISTENCY_TOKEN"
+	ErrCodeTaskNotCancellable         = "TASK_NOT_CANCELLABLE"
+	ErrCodeTaskAlreadyFinished        = "TASK_ALREADY_FINISHED"
+	ErrCodeUnknownEncryptionKey       = "UNKNOWN_ENCRYPTION_KEY"
+	ErrCodeUnsupportedVersion         = "UNSUPPORTED_VERSION"
+	ErrCodeInvalidExpansion           = "INVALID_EXPANSION"
+	ErrCodeInvalidStateTransition     = "INVALID_STATE_TRANSITION"
+	ErrCodeResourceGone               = "RESOURCE_GONE"
+	ErrCodeSyncTokenExpired           = "SYNC_TOKEN_EXPIRED"
+	ErrCodeInvalidSyncToken           = "INVALID_SYNC_TOKEN"
+	ErrCodeInvalidID                  = "INVALID_ID"
+	ErrCodeInvalidULID                = "INVALID_ULID"
+	ErrCodeInvalidDateRange           = "INVALID_DATE_RANGE"
+	ErrCodeOutOfRange                 = "OUT_OF_RANGE"
+	ErrCodeInvalidEnumValue           = "INVALID_ENUM_VALUE"
+	ErrCodeRegionRestricted           = "REGION_RESTRICTED"
+	ErrCodeChallengeRequired          = "CHALLENGE_REQUIRED"
+	ErrCodePasswordPolicy             = "PASSWORD_POLICY_VIOLATION"
+	ErrCodeMFARequired                = "MFA_REQUIRED"
+	ErrCodePasswordExpired            = "PASSWORD_EXPIRED"
+	ErrCodeEmailUnverified            = "EMAIL_UNVERIFIED"
+	ErrCodeAccountSuspended           = "ACCOUNT_SUSPENDED"
+	ErrCodeInsufficientScope          = "INSUFFICIENT_SCOPE"
+	ErrCodePaymentRequired            = "PAYMENT_REQUIRED"
+	ErrCodeUnavailableForLegalReasons = "UNAVAILABLE_FOR_LEGAL_REASONS"
+	ErrCodeInvalidCloudEvent          = "INVALID_CLOUD_EVENT"
+	ErrCodeInvalidImage               = "INVALID_IMAGE"
+	ErrCodeUploadFailed               = "UPLOAD_FAILED"
+	ErrCodeMissingFile                = "MISSING_FILE"
+	ErrCodeInvalidMultipart           = "INVALID_MULTIPART"
+	ErrCodePayloadTooLarge            = "PAYLOAD_TOO_LARGE"
+	ErrCodeNotAcceptable              = "NOT_ACCEPTABLE"
+	ErrCodeUnsupportedMediaType       = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeMethodNotAllowed           = "METHOD_NOT_ALLOWED"
+)
+
+// Sentinel errors for branching on error identity with errors.Is.
+// They are frozen, so WithDetails returns a copy rather than mutating them.
+var (
+	ErrBadRequest          = NewResponseError(ErrCodeBadRequest, "Bad request", http.StatusBadRequest).Freeze()
+	ErrUnauthorized        = NewResponseError(ErrCodeUnauthorized, "Unauthorized", http.StatusUnauthorized).Freeze()
+	ErrForbidden           = NewResponseError(ErrCodeForbidden, "Forbidden", http.StatusForbidden).Freeze()
+	ErrNotFound            = NewResponseError(ErrCodeNotFound, "Resource not found", http.StatusNotFound).Freeze()
+	ErrConflict            = NewResponseError(ErrCodeConflict, "Conflict", http.StatusConflict).Freeze()
+	ErrValidation          = NewResponseError(ErrCodeValidation, "Validation failed", http.StatusBadRequest).Freeze()
+	ErrInternalServer      = NewResponseError(ErrCodeInternalServer, "Internal server error", http.StatusInternalServerError).Freeze()
+	ErrDatabase            = NewResponseError(ErrCodeDatabase, "Database operation failed", http.StatusInternalServerError).Freeze()
+	ErrInvalidInput        = NewResponseError(ErrCodeInvalidInput, "Invalid input", http.StatusBadRequest).Freeze()
+	ErrMissingHeader       = NewResponseError(ErrCodeMissingHeader, "Missing required header", http.StatusBadRequest).Freeze()
+	ErrInvalidUUID         = NewResponseError(ErrCodeInvalidUUID, "Invalid UUID format", http.StatusBadRequest).Freeze()
+	ErrDuplicateEntry      = NewResponseError(ErrCodeDuplicateEntry, "Resource already exists", http.StatusConflict).Freeze()
+	ErrForeignKeyViolation = NewResponseError(ErrCodeForeignKeyViolation, "Foreign key violation", http.StatusBadRequest).Freeze()
+	ErrInvalidBody         = NewResponseError(ErrCodeInvalidBody, "Invalid request body", http.StatusBadRequest).Freeze()
+	ErrAccountLocked       = NewResponseError(ErrUserAccountLocked, "Account locked", http.StatusForbidden).Freeze()
 )
 
 // NewResponseError creates a new ResponseError
@@ -31,16 +102,81 @@ func NewResponseError(code string, message string, statusCode int) *ResponseErro
 		Code:       code,
 		Message:    message,
 		StatusCode: statusCode,
-		Details:    make(map[string]interface{}),
+		Details:    make(Details),
 	}
 }
 
-// WithDetails adds details to the error
+// WithDetails adds details to the error. Frozen errors are never mutated;
+// the details are added to a copy which is returned instead.
 func (e *ResponseError) WithDetails(key string, value interface{}) *ResponseError {
+	if e.frozen {
+		e = e.Clone()
+	}
+	if e.Details == nil {
+		e.Details = make(Details)
+	}
 	e.Details[key] = value
 	return e
 }
 
+// Is reports whether target is a *ResponseError with the same code,
+// so errors.Is(err, ErrNotFound) matches any NOT_FOUND error
+func (e *ResponseError) Is(target error) bool {
+	t, ok := target.(*ResponseError)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// Clone returns a mutable copy of the error with its own details map
+func (e *ResponseError) Clone() *ResponseError {
+	details := make(Details, len(e.Details))
+	for k, v := range e.Details {
+		details[k] = v
+	}
+
+	return &ResponseError{
+		Code:       e.Code,
+		Message:    e.Message,
+		StatusCode: e.StatusCode,
+		Details:    details,
+		headers:    e.headers.Clone(),
+	}
+}
+
+// WithHeader adds a response header sent with the error, such as
+// WWW-Authenticate or Link. Frozen errors are copied like in WithDetails.
+func (e *ResponseError) WithHeader(key, value string) *ResponseError {
+	if e.frozen {
+		e = e.Clone()
+	}
+	if e.headers == nil {
+		e.headers = make(http.Header)
+	}
+	e.headers.Add(key, value)
+	return e
+}
+
+// Headers returns the response headers sent with the error
+func (e *ResponseError) Headers() http.Header {
+	return e.headers.Clone()
+}
+
+// Freeze marks the error as immutable so it can be shared safely, e.g. as a
+// package-level variable. Subsequent WithDetails calls operate on copies.
+// Frozen errors are never returned to the pool.
+func (e *ResponseError) Freeze() *ResponseError {
+	e.frozen = true
+	e.pooled = false
+	return e
+}
+
+// Frozen reports whether the error is immutable
+func (e *ResponseError) Frozen() bool {
+	return e.frozen
+}
+
 // Common errors
 func BadRequest(message string) *ResponseError {
 	return NewResponseError(ErrCodeBadRequest, message, http.StatusBadRequest)
@@ -67,7 +203,7 @@ func Conflict(message string) *ResponseError {
 }
 
 func ValidationError(message string) *ResponseError {
-	return NewResponseError(ErrCodeValidation, message, http.StatusBadRequest)
+	return NewResponseError(ErrCodeValidation, message, CurrentValidationPolicy().StatusCode())
 }
 
 func InternalServerError(message string) *ResponseError {
@@ -137,3 +273,21 @@ func VersionExistsError(versionNumber string) *ResponseError {
 		http.StatusConflict,
 	)
 }
+
+func UnavailableForLegalReasons(message string, authority string) *ResponseError {
+	e := NewResponseError(ErrCodeUnavailableForLegalReasons, message, http.StatusUnavailableForLegalReasons)
+	if authority == "" {
+		return e
+	}
+	// RFC 7725 identifies the blocking authority with a blocked-by link
+	return e.WithDetails("blocked_by", authority).
+		WithHeader("Link", fmt.Sprintf(`<%s>; rel="blocked-by"`, authority))
+}
+
+func PaymentRequired(message string, requiredPlan string) *ResponseError {
+	e := NewResponseError(ErrCodePaymentRequired, message, http.StatusPaymentRequired)
+	if requiredPlan == "" {
+		return e
+	}
+	return e.WithDetails("required_plan", requiredPlan)
+}
@@ -0,0 +1,185 @@
+package responseutils
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseStatsPath is the conventional route for the response statistics handler
+const ResponseStatsPath = "/internal/response-stats"
+
+const (
+	responseStatsKey     = "responseutils.response_stats"
+	serializationTimeKey = "responseutils.serialization_time"
+)
+
+// RouteStats are the response statistics of a single route
+type RouteStats struct {
+	Route              string           `json:"route"`
+	Requests           int64            `json:"requests"`
+	Errors             int64            `json:"errors"`
+	SerializationP50Ms float64          `json:"serialization_p50_ms"`
+	SerializationP95Ms float64          `json:"serialization_p95_ms"`
+	StatusCodes        map[int]int64    `json:"status_codes"`
+	ErrorCodes         map[string]int64 `json:"error_codes"`
+}
+
+// ResponseStatsReport is sent by the statistics handler
+type ResponseStatsReport struct {
+	Since  time.Time    `json:"since"`
+	Routes []RouteStats `json:"routes"`
+}
+
+// ResponseStats collects per-route response statistics in memory, for quick
+// triage on instances without a full metrics pipeline
+type ResponseStats struct {
+	mu         sync.Mutex
+	sampleSize int
+	since      time.Time
+	routes     map[string]*routeStats
+}
+
+type routeStats struct {
+	requests    int64
+	errors      int64
+	statusCodes map[int]int64
+	errorCodes  map[string]int64
+	// samples holds the most recent serialization times
+	samples []time.Duration
+	next    int
+}
+
+// NewResponseStats creates an empty collector
+func NewResponseStats() *ResponseStats {
+	return &ResponseStats{sampleSize: 1024, since: time.Now(), routes: map[string]*routeStats{}}
+}
+
+// WithSampleSize sets how many recent serialization times per route the
+// percentiles are computed from; the default is 1024
+func (s *ResponseStats) WithSampleSize(n int) *ResponseStats {
+	if n > 0 {
+		s.sampleSize = n
+	}
+	return s
+}
+
+// Middleware records the status, error code and serialization time of every
+// response. Responses with a 5xx status count as errors.
+func (s *ResponseStats) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(responseStatsKey, s)
+		c.Next()
+
+		if c.FullPath() == "" {
+			return
+		}
+		code := ""
+		if e, ok := WrittenEnvelope(c); ok {
+			code = e.ErrorCode()
+		}
+		s.Record(c.Request.Method+" "+c.FullPath(), c.Writer.Status(), code, c.GetDuration(serializationTimeKey))
+	}
+}
+
+// Record records a response for a route; code is its error code, if any, and
+// serialization the time spent encoding its envelope
+func (s *ResponseStats) Record(route string, statusCode int, code string, serialization time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.routes[route]
+	if !ok {
+		r = &routeStats{statusCodes: map[int]int64{}, errorCodes: map[string]int64{}}
+		s.routes[route] = r
+	}
+	r.requests++
+	r.statusCodes[statusCode]++
+	if statusCode >= http.StatusInternalServerError {
+		r.errors++
+	}
+	if code != "" {
+		r.errorCodes[code]++
+	}
+	if serialization > 0 {
+		if len(r.samples) < s.sampleSize {
+			r.samples = append(r.samples, serialization)
+		} else {
+			r.samples[r.next%len(r.samples)] = serialization
+			r.next++
+		}
+	}
+}
+
+// Snapshot returns the statistics of every route, sorted by route
+func (s *ResponseStats) Snapshot() []RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(s.routes))
+	for route, r := range s.routes {
+		rs := RouteStats{
+			Route:       route,
+			Requests:    r.requests,
+			Errors:      r.errors,
+			StatusCodes: make(map[int]int64, len(r.statusCodes)),
+			ErrorCodes:  make(map[string]int64, len(r.errorCodes)),
+		}
+		for k, v := range r.statusCodes {
+			rs.StatusCodes[k] = v
+		}
+		for k, v := range r.errorCodes {
+			rs.ErrorCodes[k] = v
+		}
+
+		samples := append([]time.Duration(nil), r.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		rs.SerializationP50Ms = percentileMs(samples, 0.50)
+		rs.SerializationP95Ms = percentileMs(samples, 0.95)
+		stats = append(stats, rs)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Route < stats[j].Route
+	})
+	return stats
+}
+
+// Reset clears the collected statistics
+func (s *ResponseStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = map[string]*routeStats{}
+	s.since = time.Now()
+}
+
+// Handler sends the statistics of every route along with the time
+// collection started
+func (s *ResponseStats) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.mu.Lock()
+		since := s.since
+		s.mu.Unlock()
+
+		OKResponse(c, ResponseStatsReport{Since: since.UTC(), Routes: s.Snapshot()}, "Response statistics retrieved successfully")
+	}
+}
+
+// RegisterHandler registers the statistics handler on ResponseStatsPath
+func (s *ResponseStats) RegisterHandler(r gin.IRoutes) {
+	r.GET(ResponseStatsPath, s.Handler())
+}
+
+// percentileMs returns the nearest-rank percentile of sorted durations in milliseconds
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p+0.5) - 1
+	i = min(max(i, 0), len(sorted)-1)
+	return float64(sorted[i].Microseconds()) / 1000
+}
@@ -1,52 +1,119 @@
 package responseutils
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SuccessResponse sends a success response
+// SuccessResponse sends a success response, as plain text on MinimalMode
+// routes when the client negotiates text/plain. Relations requested with
+// expand= are inlined by the route's Expanders.
 func SuccessResponse(c *gin.Context, statusCode int, data interface{}, message string) {
-	c.JSON(statusCode, Response{
+	if writeMinimal(c, statusCode, data, message) {
+		return
+	}
+
+	data, err := applyExpansions(c, data)
+	if err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	writeJSON(c, statusCode, Response{
 		Success: true,
 		Data:    data,
 		Message: message,
 	})
 }
 
-// ErrorResponse sends an error response
+// ErrorResponse sends an error response. Browsers preferring text/html get an
+// HTML error page when enabled with EnableHTMLErrorPages, and errors are sent
+// as RFC 7807 problem details when FeatureProblemDetails is enabled. Errors
+// from AcquireResponseError are released once written.
 func ErrorResponse(c *gin.Context, err error) {
-	if appErr, ok := err.(*ResponseError); ok {
-		c.JSON(appErr.StatusCode, Response{
-			Success: false,
-			Error: map[string]interface{}{
-				"code":    appErr.Code,
-				"message": appErr.Message,
-				"details": appErr.Details,
-			},
-		})
+	applyErrorHeaders(c, err)
+
+	if writeErrorPage(c, err) {
+		releaseWrittenError(c, err)
 		return
 	}
 
-	// Default to internal server error for unknown errors
-	c.JSON(http.StatusInternalServerError, Response{
+	if FeatureEnabled(c, FeatureProblemDetails) {
+		ProblemResponse(c, err)
+		return
+	}
+
+	statusCode, body := errorBody(err)
+	writeJSON(c, statusCode, Response{
 		Success: false,
-		Error: map[string]interface{}{
-			"code":    ErrCodeInternalServer,
-			"message": "An unexpected error occurred",
-			"details": map[string]interface{}{"error": err.Error()},
-		},
+		Error:   body,
 	})
+	releaseWrittenError(c, err)
+}
+
+// errorBody converts an error into its status code and error payload
+func errorBody(err error) (int, map[string]interface{}) {
+	var appErr *ResponseError
+	if errors.As(err, &appErr) {
+		return validationStatus(appErr), map[string]interface{}{
+			"code":    appErr.Code,
+			"message": appErr.Message,
+			"details": appErr.Details,
+		}
+	}
+
+	if known := mapKnownError(err); known != nil {
+		return errorBody(known)
+	}
+
+	// Default to internal server error for unknown errors
+	return http.StatusInternalServerError, map[string]interface{}{
+		"code":    ErrCodeInternalServer,
+		"message": "An unexpected error occurred",
+		"details": Details{"error": err.Error()},
+	}
+}
+
+// applyErrorHeaders sets the response headers carried by a ResponseError
+func applyErrorHeaders(c *gin.Context, err error) {
+	var appErr *ResponseError
+	if !errors.As(err, &appErr) {
+		return
+	}
+	for key, values := range appErr.headers {
+		c.Writer.Header().Del(key)
+		for _, v := range values {
+			c.Writer.Header().Add(key, v)
+		}
+	}
+}
+
+// mapKnownError converts well-known standard library errors into their
+// ResponseError equivalent, returning nil for any other error
+func mapKnownError(err error) *ResponseError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return PayloadTooLarge(maxBytesErr.Limit)
+	}
+
+	if isMultipartError(err) {
+		return MultipartError(err)
+	}
+
+	return nil
 }
 
 // CreatedResponse sends a 201 Created response
 func CreatedResponse(c *gin.Context, data interface{}, message string) {
+	recordWriteEvent(c, OperationCreated, data)
 	SuccessResponse(c, http.StatusCreated, data, message)
 }
 
 // CreatedResponse sends a 201 Created response
 func UpdatedResponse(c *gin.Context, data interface{}, message string) {
+	recordWriteEvent(c, OperationUpdated, data)
 	SuccessResponse(c, http.StatusAccepted, data, message)
 }
 
@@ -55,14 +122,61 @@ func OKResponse(c *gin.Context, data interface{}, message string) {
 	SuccessResponse(c, http.StatusOK, data, message)
 }
 
+// AcceptedResponse sends a 202 Accepted response for work continuing in the
+// background, with a Location header pointing at its status resource
+func AcceptedResponse(c *gin.Context, data interface{}, location string) {
+	if location != "" {
+		c.Header("Location", location)
+	}
+	SuccessResponse(c, http.StatusAccepted, data, "Request accepted for processing")
+}
+
 // NoContentResponse sends a 204 No Content response
 func NoContentResponse(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// ListResponseWithPagination sends a paginated list response
+// DegradedResponse sends a 200 OK partial response listing the unavailable components
+func DegradedResponse(c *gin.Context, data interface{}, missing []string) {
+	if missing == nil {
+		missing = []string{}
+	}
+
+	writeJSON(c, http.StatusOK, DegradedResponseDTO{
+		Success:     true,
+		Data:        data,
+		Degraded:    true,
+		Unavailable: missing,
+		Message:     "Some components are unavailable",
+	})
+}
+
+// ListResponseWithPagination sends a paginated list response. When the
+// collection's version was recorded with SetCollectionVersion it sets the
+// ETag header and sends 304 Not Modified if the client's copy is current.
+// Soft-deleted items are omitted or marked following SetTombstonePolicy, and
+// relations requested with expand= are inlined by the route's Expanders.
+// Empty lists are sent as an empty data array, or as 404 Not Found under the
+// EmptyListNotFound policy.
 func ListResponseWithPagination(c *gin.Context, data interface{}, pagination *Pagination) {
-	c.JSON(http.StatusOK, ListResponse{
+	if writeNotModified(c) {
+		return
+	}
+	if isEmptyList(data) {
+		if CurrentEmptyListPolicy() == EmptyListNotFound {
+			ErrorResponse(c, ErrNotFound)
+			return
+		}
+		data = emptyListOf(data)
+	}
+
+	data, err := applyExpansions(c, applyTombstonePolicy(c, data))
+	if err != nil {
+		ErrorResponse(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, ListResponse{
 		Success:    true,
 		Data:       data,
 		Pagination: pagination,
@@ -0,0 +1,49 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InsufficientScope creates a 403 error listing the OAuth scopes the request
+// lacks, with the RFC 6750 WWW-Authenticate insufficient_scope challenge
+func InsufficientScope(required []string) *ResponseError {
+	if required == nil {
+		required = []string{}
+	}
+
+	return NewResponseError(
+		ErrCodeInsufficientScope,
+		"The access token does not grant the required scopes",
+		http.StatusForbidden,
+	).WithDetails("required_scopes", required).
+		WithHeader("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(required, " ")))
+}
+
+// RequireScopes returns middleware rejecting requests whose token, as
+// returned by granted, lacks any of the required scopes with the
+// InsufficientScope error
+func RequireScopes(granted func(c *gin.Context) []string, required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		have := make(map[string]bool)
+		for _, s := range granted(c) {
+			have[s] = true
+		}
+
+		var missing []string
+		for _, s := range required {
+			if !have[s] {
+				missing = append(missing, s)
+			}
+		}
+		if len(missing) > 0 {
+			ErrorResponse(c, InsufficientScope(missing))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
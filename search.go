@@ -0,0 +1,238 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchCursorParam is the conventional query parameter carrying a search cursor
+const SearchCursorParam = "cursor"
+
+// SearchResponse is a search result page, independent of the search engine
+// that produced it
+// @Description Search response structure
+type SearchResponse struct {
+	Success bool        `json:"success" example:"true"`
+	Hits    []SearchHit `json:"hits"`
+	Total   int64       `json:"total" example:"1342"`
+	// TotalRelation is "gte" when Total is a lower bound, as engines stop
+	// counting exactly past a threshold
+	TotalRelation string                         `json:"total_relation,omitempty" example:"eq"`
+	Facets        map[string][]FacetBucket       `json:"facets,omitempty"`
+	Highlights    map[string]map[string][]string `json:"highlights,omitempty"`
+	TookMs        int64                          `json:"took_ms" example:"12"`
+	Cursor        string                         `json:"cursor,omitempty" example:"eyJhIjpbMS4yLCJkb2MtNDIiXX0"`
+}
+
+// SearchHit is a single search result. Source is embedded as-is.
+// @Description Search hit structure
+type SearchHit struct {
+	ID     string          `json:"id" example:"doc-42"`
+	Score  *float64        `json:"score,omitempty" example:"1.2"`
+	Source json.RawMessage `json:"source,omitempty" swaggertype:"object"`
+}
+
+// FacetBucket is one value of a facet and the number of hits having it
+// @Description Facet bucket structure
+type FacetBucket struct {
+	Value string `json:"value" example:"electronics"`
+	Count int64  `json:"count" example:"57"`
+}
+
+// SearchCursor is the position a search cursor encodes: the sort values of
+// the last hit sent, for search_after, and the point in time searched, if any
+type SearchCursor struct {
+	PIT         string        `json:"p,omitempty"`
+	SearchAfter []interface{} `json:"a"`
+}
+
+// IsZero reports whether the cursor is empty, meaning the first page
+func (c SearchCursor) IsZero() bool {
+	return c.PIT == "" && len(c.SearchAfter) == 0
+}
+
+// EncodeSearchCursor encodes a cursor for SearchResponse.Cursor
+func EncodeSearchCursor(cursor SearchCursor) string {
+	if cursor.IsZero() {
+		return ""
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeSearchCursor decodes a cursor from a previous page. An empty cursor
+// decodes to the zero cursor. Sort values keep their JSON number form so
+// they can be passed back to the engine's search_after unchanged.
+func DecodeSearchCursor(cursor string) (SearchCursor, *ResponseError) {
+	if cursor == "" {
+		return SearchCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return SearchCursor{}, InvalidSearchCursor()
+	}
+	var sc SearchCursor
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&sc); err != nil || sc.IsZero() {
+		return SearchCursor{}, InvalidSearchCursor()
+	}
+	return sc, nil
+}
+
+// ParseSearchCursor decodes the cursor query parameter, see DecodeSearchCursor
+func ParseSearchCursor(c *gin.Context) (SearchCursor, *ResponseError) {
+	return DecodeSearchCursor(c.Query(SearchCursorParam))
+}
+
+// SearchResultsResponse sends a 200 OK search result page
+func SearchResultsResponse(c *gin.Context, results SearchResponse) {
+	results.Success = true
+	if results.Hits == nil {
+		results.Hits = []SearchHit{}
+	}
+	writeJSON(c, http.StatusOK, results)
+}
+
+func InvalidSearchCursor() *ResponseError {
+	return NewResponseError(ErrCodeInvalidSearchCursor, "The search cursor is invalid", http.StatusBadRequest)
+}
+
+// esResponse is the subset of an Elasticsearch or OpenSearch _search
+// response mapped into a SearchResponse
+type esResponse struct {
+	Took  int64  `json:"took"`
+	PITID string `json:"pit_id"`
+	Hits  struct {
+		Total json.RawMessage `json:"total"`
+		Hits  []struct {
+			ID        string              `json:"_id"`
+			Score     *float64            `json:"_score"`
+			Source    json.RawMessage     `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+			Sort      []interface{}       `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]json.RawMessage `json:"aggregations"`
+	Error        json.RawMessage            `json:"error"`
+}
+
+type esBucket struct {
+	Key         json.RawMessage `json:"key"`
+	KeyAsString string          `json:"key_as_string"`
+	DocCount    int64           `json:"doc_count"`
+}
+
+// FromElasticsearch maps the body of an Elasticsearch or OpenSearch _search
+// response into a SearchResponse. Bucket aggregations, such as terms, range
+// and filters, become facets; metric and nested aggregations are skipped.
+//
+// When the page is full (pageSize hits, all with sort values) the cursor
+// carries the last hit's sort values and the point in time, so the next page
+// is fetched with search_after; pass pageSize 0 to always set it.
+func FromElasticsearch(body []byte, pageSize int) (SearchResponse, error) {
+	var es esResponse
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&es); err != nil {
+		return SearchResponse{}, fmt.Errorf("responseutils: decoding search response: %w", err)
+	}
+	if len(es.Error) > 0 && string(es.Error) != "null" {
+		return SearchResponse{}, fmt.Errorf("responseutils: search failed: %s", es.Error)
+	}
+
+	resp := SearchResponse{TookMs: es.Took, Hits: make([]SearchHit, 0, len(es.Hits.Hits))}
+	resp.Total, resp.TotalRelation = esTotal(es.Hits.Total)
+
+	var lastSort []interface{}
+	for _, h := range es.Hits.Hits {
+		resp.Hits = append(resp.Hits, SearchHit{ID: h.ID, Score: h.Score, Source: h.Source})
+		if len(h.Highlight) > 0 {
+			if resp.Highlights == nil {
+				resp.Highlights = map[string]map[string][]string{}
+			}
+			resp.Highlights[h.ID] = h.Highlight
+		}
+		lastSort = h.Sort
+	}
+	if len(lastSort) > 0 && (pageSize <= 0 || len(es.Hits.Hits) >= pageSize) {
+		resp.Cursor = EncodeSearchCursor(SearchCursor{PIT: es.PITID, SearchAfter: lastSort})
+	}
+
+	for name, raw := range es.Aggregations {
+		if buckets, ok := esBuckets(raw); ok {
+			if resp.Facets == nil {
+				resp.Facets = map[string][]FacetBucket{}
+			}
+			resp.Facets[name] = buckets
+		}
+	}
+	return resp, nil
+}
+
+// esTotal reads hits.total, an object with value and relation since
+// Elasticsearch 7 and a plain number before
+func esTotal(raw json.RawMessage) (int64, string) {
+	var total struct {
+		Value    int64  `json:"value"`
+		Relation string `json:"relation"`
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value, total.Relation
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n, "eq"
+	}
+	return 0, ""
+}
+
+// esBuckets reads the buckets of a bucket aggregation, either a list (terms,
+// range, histogram) or an object keyed by filter name (filters)
+func esBuckets(raw json.RawMessage) ([]FacetBucket, bool) {
+	var agg struct {
+		Buckets json.RawMessage `json:"buckets"`
+	}
+	if err := json.Unmarshal(raw, &agg); err != nil || len(agg.Buckets) == 0 {
+		return nil, false
+	}
+
+	var list []esBucket
+	if err := json.Unmarshal(agg.Buckets, &list); err == nil {
+		buckets := make([]FacetBucket, 0, len(list))
+		for _, b := range list {
+			buckets = append(buckets, FacetBucket{Value: esBucketKey(b), Count: b.DocCount})
+		}
+		return buckets, true
+	}
+
+	var named map[string]esBucket
+	if err := json.Unmarshal(agg.Buckets, &named); err != nil {
+		return nil, false
+	}
+	buckets := make([]FacetBucket, 0, len(named))
+	for name, b := range named {
+		buckets = append(buckets, FacetBucket{Value: name, Count: b.DocCount})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Value < buckets[j].Value })
+	return buckets, true
+}
+
+func esBucketKey(b esBucket) string {
+	if b.KeyAsString != "" {
+		return b.KeyAsString
+	}
+	var s string
+	if err := json.Unmarshal(b.Key, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(b.Key))
+}
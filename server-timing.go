@@ -0,0 +1,145 @@
+package responseutils
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const timingKey = "responseutils.timing"
+
+// SlowResponse describes a response that exceeded its latency budget
+type SlowResponse struct {
+	Method     string
+	Route      string
+	StatusCode int
+	Elapsed    time.Duration
+	Threshold  time.Duration
+}
+
+// TimingOptions configure TimingMiddleware
+type TimingOptions struct {
+	// ServerTiming sends a Server-Timing header on envelope responses with
+	// the handler ("app") and serialization ("encode") durations and any
+	// metrics added with AddServerTiming
+	ServerTiming bool
+	// SlowThreshold is the latency budget; requests taking longer are slow.
+	// 0 disables slow response detection.
+	SlowThreshold time.Duration
+	// SlowMeta adds meta.slow: true to envelopes written after the threshold
+	SlowMeta bool
+	// SlowLog receives a warning line per slow response; nil uses
+	// gin.DefaultErrorWriter
+	SlowLog io.Writer
+	// OnSlow is called for every slow response, e.g. to keep its trace
+	OnSlow func(c *gin.Context, slow SlowResponse)
+}
+
+type requestTiming struct {
+	opts  TimingOptions
+	start time.Time
+
+	mu      sync.Mutex
+	metrics []string
+}
+
+// TimingMiddleware returns middleware timing every request for the
+// Server-Timing header and slow response detection
+func TimingMiddleware(opts TimingOptions) gin.HandlerFunc {
+	var logMu sync.Mutex
+
+	return func(c *gin.Context) {
+		t := &requestTiming{opts: opts, start: time.Now()}
+		c.Set(timingKey, t)
+		c.Next()
+
+		elapsed := time.Since(t.start)
+		if opts.SlowThreshold <= 0 || elapsed <= opts.SlowThreshold {
+			return
+		}
+		slow := SlowResponse{
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			StatusCode: c.Writer.Status(),
+			Elapsed:    elapsed,
+			Threshold:  opts.SlowThreshold,
+		}
+
+		w := opts.SlowLog
+		if w == nil {
+			w = gin.DefaultErrorWriter
+		}
+		logMu.Lock()
+		fmt.Fprintf(w, "[RESPONSE-UTILS-warning] slow response: %s %s %d took %s (budget %s)\n",
+			slow.Method, slow.Route, slow.StatusCode, slow.Elapsed.Round(time.Millisecond), slow.Threshold)
+		logMu.Unlock()
+
+		if opts.OnSlow != nil {
+			opts.OnSlow(c, slow)
+		}
+	}
+}
+
+// AddServerTiming adds a metric to the request's Server-Timing header, e.g.
+// AddServerTiming(c, "db", 12*time.Millisecond, "orders query")
+func AddServerTiming(c *gin.Context, name string, d time.Duration, description string) {
+	t, ok := requestTimingOf(c)
+	if !ok {
+		return
+	}
+	metric := name + ";dur=" + formatTimingMs(d)
+	if description != "" {
+		metric += ";desc=" + strconv.Quote(description)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, metric)
+}
+
+// IsSlow reports whether the request has exceeded the latency budget of its
+// TimingMiddleware so far
+func IsSlow(c *gin.Context) bool {
+	t, ok := requestTimingOf(c)
+	return ok && t.opts.SlowThreshold > 0 && time.Since(t.start) > t.opts.SlowThreshold
+}
+
+func requestTimingOf(c *gin.Context) (*requestTiming, bool) {
+	v, ok := c.Get(timingKey)
+	if !ok {
+		return nil, false
+	}
+	return v.(*requestTiming), true
+}
+
+// applySlowMeta marks envelopes written after the latency budget
+func applySlowMeta(c *gin.Context, e *Envelope) {
+	if t, ok := requestTimingOf(c); ok && t.opts.SlowMeta && IsSlow(c) {
+		e.SetMeta("slow", true)
+	}
+}
+
+// applyServerTiming sets the Server-Timing header of a response about to be
+// written, splitting the time so far into the handler and serialization
+func applyServerTiming(c *gin.Context) {
+	t, ok := requestTimingOf(c)
+	if !ok || !t.opts.ServerTiming {
+		return
+	}
+	encode := c.GetDuration(serializationTimeKey)
+	app := time.Since(t.start) - encode
+
+	t.mu.Lock()
+	metrics := append([]string{"app;dur=" + formatTimingMs(app), "encode;dur=" + formatTimingMs(encode)}, t.metrics...)
+	t.mu.Unlock()
+	c.Header("Server-Timing", strings.Join(metrics, ", "))
+}
+
+func formatTimingMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+}
@@ -0,0 +1,284 @@
+// Package shadow replays requests against two handler versions and diffs
+// their responses, for validating that migrating a service onto
+// response-utils doesn't change its payloads.
+//
+// Compare runs both handlers for a single request. Handler serves clients
+// from the baseline and compares the candidate in the background, so it can
+// be mounted in front of production traffic; it only replays safe methods
+// unless told otherwise.
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	responseutils "github.com/geekible-ltd/response-utils"
+)
+
+// DefaultMaxReplays is the number of candidate replays Handler runs at once
+const DefaultMaxReplays = 16
+
+// Options control normalization before responses are compared, and which
+// requests Handler replays
+type Options struct {
+	// IgnoreFields are dot-separated body paths left out of the comparison,
+	// e.g. "meta.request_id"; "*" matches any object key or array index
+	IgnoreFields []string
+	// CompareHeaders are response headers that must match; others are ignored
+	CompareHeaders []string
+	// ReplayMethods are the methods Handler replays against the candidate;
+	// defaults to GET, HEAD and OPTIONS, as replaying other methods would
+	// repeat their side effects
+	ReplayMethods []string
+	// MaxReplays bounds the replays Handler runs at once; requests arriving
+	// while all are busy are not compared. Defaults to DefaultMaxReplays.
+	MaxReplays int
+}
+
+// Mismatch is a difference between the baseline and candidate responses
+type Mismatch struct {
+	Path      string      `json:"path"`
+	Baseline  interface{} `json:"baseline"`
+	Candidate interface{} `json:"candidate"`
+}
+
+// String formats the mismatch for logs
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %v != %v", m.Path, m.Baseline, m.Candidate)
+}
+
+// Report is the result of comparing one request
+type Report struct {
+	Method          string     `json:"method"`
+	Path            string     `json:"path"`
+	BaselineStatus  int        `json:"baseline_status"`
+	CandidateStatus int        `json:"candidate_status"`
+	Mismatches      []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Match reports whether the responses are equivalent
+func (r *Report) Match() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Compare serves req with both handlers and diffs the normalized responses
+func Compare(req *http.Request, baseline, candidate http.Handler, opts Options) (*Report, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	base := responseutils.CaptureResponse(baseline, withBody(req, body))
+	cand := responseutils.CaptureResponse(candidate, withBody(req, body))
+	return Diff(req, base, cand, opts), nil
+}
+
+// Handler serves clients from baseline and replays requests against
+// candidate in the background, passing every report with mismatches to
+// onMismatch. Only requests with one of opts.ReplayMethods are replayed, at
+// most opts.MaxReplays at once; other requests are served by baseline alone.
+func Handler(baseline, candidate http.Handler, opts Options, onMismatch func(*Report)) http.Handler {
+	methods := opts.ReplayMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+	replayed := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		replayed[strings.ToUpper(m)] = true
+	}
+	if opts.MaxReplays <= 0 {
+		opts.MaxReplays = DefaultMaxReplays
+	}
+	slots := make(chan struct{}, opts.MaxReplays)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !replayed[req.Method] {
+			baseline.ServeHTTP(w, req)
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			baseline.ServeHTTP(w, req)
+			return
+		}
+
+		body, err := readBody(req)
+		if err != nil {
+			<-slots
+			baseline.ServeHTTP(w, req)
+			return
+		}
+
+		base := responseutils.CaptureResponse(baseline, withBody(req, body))
+		base.WriteTo(w)
+
+		// The request context is cancelled once the client is answered
+		replay := withBody(req.WithContext(context.WithoutCancel(req.Context())), body)
+		go func() {
+			defer func() { <-slots }()
+			cand := responseutils.CaptureResponse(candidate, replay)
+			if report := Diff(replay, base, cand, opts); !report.Match() {
+				onMismatch(report)
+			}
+		}()
+	})
+}
+
+// Diff compares two captured responses for req
+func Diff(req *http.Request, baseline, candidate *responseutils.CapturedResponse, opts Options) *Report {
+	report := &Report{
+		Method:          req.Method,
+		Path:            req.URL.Path,
+		BaselineStatus:  baseline.StatusCode,
+		CandidateStatus: candidate.StatusCode,
+	}
+
+	if baseline.StatusCode != candidate.StatusCode {
+		report.Mismatches = append(report.Mismatches, Mismatch{Path: "status", Baseline: baseline.StatusCode, Candidate: candidate.StatusCode})
+	}
+	for _, h := range opts.CompareHeaders {
+		if b, c := baseline.Header.Get(h), candidate.Header.Get(h); b != c {
+			report.Mismatches = append(report.Mismatches, Mismatch{Path: "header." + http.CanonicalHeaderKey(h), Baseline: b, Candidate: c})
+		}
+	}
+
+	b, bErr := Normalize(baseline.Body, opts.IgnoreFields)
+	c, cErr := Normalize(candidate.Body, opts.IgnoreFields)
+	if bErr != nil || cErr != nil {
+		// Not JSON, compare the raw bodies
+		if !bytes.Equal(baseline.Body, candidate.Body) {
+			report.Mismatches = append(report.Mismatches, Mismatch{Path: "body", Baseline: string(baseline.Body), Candidate: string(candidate.Body)})
+		}
+		return report
+	}
+
+	report.Mismatches = append(report.Mismatches, DiffValues("body", b, c)...)
+	return report
+}
+
+// Normalize decodes a JSON body, preserving number precision, and removes
+// the ignored fields
+func Normalize(body []byte, ignore []string) (interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	for _, path := range ignore {
+		v = removePath(v, strings.Split(path, "."))
+	}
+	return v, nil
+}
+
+// DiffValues returns the differences between two normalized values, with
+// paths relative to root and sorted for stable reports
+func DiffValues(root string, baseline, candidate interface{}) []Mismatch {
+	var out []Mismatch
+	diffValues(root, baseline, candidate, &out)
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func diffValues(path string, a, b interface{}, out *[]Mismatch) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok {
+				*out = append(*out, Mismatch{Path: path + "." + k, Baseline: v, Candidate: nil})
+				continue
+			}
+			diffValues(path+"."+k, v, other, out)
+		}
+		for k, v := range bv {
+			if _, ok := av[k]; !ok {
+				*out = append(*out, Mismatch{Path: path + "." + k, Baseline: nil, Candidate: v})
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if len(av) != len(bv) {
+			*out = append(*out, Mismatch{Path: path + ".length", Baseline: len(av), Candidate: len(bv)})
+		}
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			diffValues(path+"."+strconv.Itoa(i), av[i], bv[i], out)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, Mismatch{Path: path, Baseline: a, Candidate: b})
+	}
+}
+
+// removePath deletes the value at path, expanding "*" segments
+func removePath(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+	head, rest := path[0], path[1:]
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if head != "*" && head != k {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(node, k)
+			} else {
+				node[k] = removePath(child, rest)
+			}
+		}
+	case []interface{}:
+		for i, child := range node {
+			if head != "*" && head != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) == 0 {
+				node[i] = nil
+			} else {
+				node[i] = removePath(child, rest)
+			}
+		}
+	}
+	return v
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+func withBody(req *http.Request, body []byte) *http.Request {
+	r := req.Clone(req.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return r
+}
@@ -0,0 +1,50 @@
+package responseutils
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+// StartEventStream writes the headers of a server-sent events stream. Events
+// are then sent with StreamEvent until the handler returns.
+func StartEventStream(c *gin.Context) {
+	c.Header("Content-Type", eventStreamContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx and similar proxies from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+}
+
+// StreamEvent sends a server-sent event with data serialized by the current
+// encoder, and flushes it to the client
+func StreamEvent(c *gin.Context, event string, data interface{}) error {
+	payload, err := CurrentEncoder().Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + strings.ReplaceAll(event, "\n", "") + "\n")
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(payload, "\n"), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	if _, err := c.Writer.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
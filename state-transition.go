@@ -0,0 +1,41 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// InvalidStateTransition creates a 409 error for a transition a resource's
+// state machine does not permit, listing the states reachable from its
+// current state in details.allowed_transitions
+func InvalidStateTransition(resource, from, to string, allowed []string) *ResponseError {
+	if allowed == nil {
+		allowed = []string{}
+	}
+
+	message := fmt.Sprintf("%s cannot transition from '%s' to '%s'", resource, from, to)
+	if len(allowed) == 0 {
+		message += fmt.Sprintf(": '%s' is a final state", from)
+	} else {
+		message += fmt.Sprintf(": allowed transitions are %s", strings.Join(allowed, ", "))
+	}
+
+	return NewResponseError(ErrCodeInvalidStateTransition, message, http.StatusConflict).
+		WithDetails("resource", resource).
+		WithDetails("from", from).
+		WithDetails("to", to).
+		WithDetails("allowed_transitions", allowed)
+}
+
+// CheckTransition returns the InvalidStateTransition error when transitions,
+// mapping each state to the states reachable from it, does not permit moving
+// from one state to another, otherwise nil
+func CheckTransition(resource, from, to string, transitions map[string][]string) *ResponseError {
+	for _, s := range transitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return InvalidStateTransition(resource, from, to, transitions[from])
+}
@@ -0,0 +1,75 @@
+package responseutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticResponse is a success envelope serialized once by PrecompileResponse
+// and written from its cached bytes on every request
+type StaticResponse struct {
+	envelope Envelope
+	data     []byte
+	etag     string
+}
+
+// PrecompileResponse serializes a success envelope for a static endpoint,
+// such as configuration, enums or health, with the current encoder. Call it
+// at startup: it panics if data cannot be encoded, like regexp.MustCompile.
+//
+// The cached bytes are written as they are, so request-specific additions
+// such as meta, links, response defaults and response hooks are not applied.
+// Compression, digests, signing, encryption and HEAD handling still are.
+func PrecompileResponse(statusCode int, data interface{}, message string) StaticResponse {
+	e := Envelope{
+		StatusCode:  statusCode,
+		ContentType: jsonContentType,
+		Body:        Response{Success: true, Data: data, Message: message},
+	}
+	encoded, err := encodeEnvelope(CurrentEncoder(), &e)
+	if err != nil {
+		panic(fmt.Sprintf("responseutils: precompiling response: %v", err))
+	}
+
+	sum := sha256.Sum256(encoded)
+	return StaticResponse{
+		envelope: e,
+		data:     encoded,
+		etag:     `W/"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+// Write sends the cached response with its ETag, answering a matching
+// If-None-Match with 304 Not Modified. The ETag is weak because compression
+// and encryption may still change the bytes on the wire.
+func (s StaticResponse) Write(c *gin.Context) {
+	c.Header("ETag", s.etag)
+	if c.Request != nil && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+		etagMatches(c.GetHeader("If-None-Match"), s.etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	e := s.envelope
+	writeEncoded(c, &e, s.data)
+}
+
+// Handler returns a handler writing the cached response
+func (s StaticResponse) Handler() gin.HandlerFunc {
+	return s.Write
+}
+
+// ETag returns the weak entity tag of the cached body
+func (s StaticResponse) ETag() string {
+	return s.etag
+}
+
+// Bytes returns a copy of the cached body
+func (s StaticResponse) Bytes() []byte {
+	return append([]byte(nil), s.data...)
+}
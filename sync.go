@@ -0,0 +1,150 @@
+package responseutils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncTokenParam is the conventional query parameter carrying a sync token
+const SyncTokenParam = "sync_token"
+
+// SyncResponseDTO is an incremental sync result: the items changed and the
+// IDs deleted since the client's token, and the token for the next sync
+// @Description Sync response structure
+type SyncResponseDTO struct {
+	Success       bool        `json:"success" example:"true"`
+	Items         interface{} `json:"items"`
+	DeletedIDs    []string    `json:"deleted_ids"`
+	NextSyncToken string      `json:"next_sync_token" example:"eyJwIjoiMTA0MiJ9"`
+	HasMore       bool        `json:"has_more" example:"false"`
+}
+
+// SyncToken is the server-side position a sync token encodes, e.g. a change
+// sequence number or the updated_at of the last change sent
+type SyncToken struct {
+	Position string    `json:"p"`
+	IssuedAt time.Time `json:"t"`
+}
+
+// IsZero reports whether the token is empty, meaning the client needs a full sync
+func (t SyncToken) IsZero() bool {
+	return t.Position == ""
+}
+
+var (
+	syncTokenMu     sync.RWMutex
+	syncTokenKey    []byte
+	syncTokenMaxAge time.Duration
+)
+
+// SetSyncTokenKey sets the key signing sync tokens, so clients cannot forge
+// positions. Tokens are unsigned when no key is set.
+func SetSyncTokenKey(key []byte) {
+	syncTokenMu.Lock()
+	defer syncTokenMu.Unlock()
+	syncTokenKey = append([]byte(nil), key...)
+}
+
+// SetSyncTokenMaxAge sets how long a sync token stays valid, e.g. the
+// retention of the deletion log; 0 means tokens never expire
+func SetSyncTokenMaxAge(d time.Duration) {
+	syncTokenMu.Lock()
+	defer syncTokenMu.Unlock()
+	syncTokenMaxAge = d
+}
+
+// EncodeSyncToken encodes an opaque token for position, issued now
+func EncodeSyncToken(position string) string {
+	payload, _ := json.Marshal(SyncToken{Position: position, IssuedAt: time.Now().UTC()})
+	token := base64.RawURLEncoding.EncodeToString(payload)
+
+	syncTokenMu.RLock()
+	defer syncTokenMu.RUnlock()
+	if len(syncTokenKey) == 0 {
+		return token
+	}
+	return token + "." + base64.RawURLEncoding.EncodeToString(signSyncToken(syncTokenKey, token))
+}
+
+// DecodeSyncToken decodes a token produced by EncodeSyncToken. An empty token
+// decodes to the zero SyncToken. Malformed or tampered tokens are
+// INVALID_SYNC_TOKEN errors and expired tokens SYNC_TOKEN_EXPIRED errors
+// telling the client to discard its data and resync from scratch.
+func DecodeSyncToken(token string) (SyncToken, *ResponseError) {
+	if token == "" {
+		return SyncToken{}, nil
+	}
+
+	syncTokenMu.RLock()
+	key, maxAge := syncTokenKey, syncTokenMaxAge
+	syncTokenMu.RUnlock()
+
+	payload, sig, signed := strings.Cut(token, ".")
+	if len(key) > 0 {
+		got, err := base64.RawURLEncoding.DecodeString(sig)
+		if !signed || err != nil || !hmac.Equal(got, signSyncToken(key, payload)) {
+			return SyncToken{}, InvalidSyncToken()
+		}
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return SyncToken{}, InvalidSyncToken()
+	}
+	var t SyncToken
+	if err := json.Unmarshal(raw, &t); err != nil || t.IsZero() {
+		return SyncToken{}, InvalidSyncToken()
+	}
+
+	if maxAge > 0 && time.Since(t.IssuedAt) > maxAge {
+		return SyncToken{}, SyncTokenExpired()
+	}
+	return t, nil
+}
+
+// ParseSyncToken decodes the sync_token query parameter, see DecodeSyncToken
+func ParseSyncToken(c *gin.Context) (SyncToken, *ResponseError) {
+	return DecodeSyncToken(c.Query(SyncTokenParam))
+}
+
+// SyncResponse sends a 200 OK sync result with a token for nextPosition.
+// hasMore tells the client to sync again immediately with the new token.
+func SyncResponse(c *gin.Context, items interface{}, deletedIDs []string, nextPosition string, hasMore bool) {
+	if deletedIDs == nil {
+		deletedIDs = []string{}
+	}
+
+	writeJSON(c, http.StatusOK, SyncResponseDTO{
+		Success:       true,
+		Items:         items,
+		DeletedIDs:    deletedIDs,
+		NextSyncToken: EncodeSyncToken(nextPosition),
+		HasMore:       hasMore,
+	})
+}
+
+func InvalidSyncToken() *ResponseError {
+	return NewResponseError(ErrCodeInvalidSyncToken, "The sync token is invalid", http.StatusBadRequest)
+}
+
+func SyncTokenExpired() *ResponseError {
+	return NewResponseError(
+		ErrCodeSyncTokenExpired,
+		"The sync token has expired; discard local data and perform a full sync",
+		http.StatusGone,
+	)
+}
+
+func signSyncToken(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
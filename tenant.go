@@ -0,0 +1,112 @@
+package responseutils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader is the default header carrying the tenant ID
+const TenantHeader = "X-Tenant-ID"
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// TenantOptions configure TenantMiddleware
+type TenantOptions struct {
+	// Header carrying the tenant ID; defaults to X-Tenant-ID
+	Header string
+	// BaseDomain enables tenant subdomains: a request to acme.example.com
+	// with BaseDomain "example.com" belongs to tenant "acme". When the
+	// header is also sent it must name the same tenant.
+	BaseDomain string
+	// Optional lets requests without a tenant through instead of answering
+	// 400 MISSING_HEADER
+	Optional bool
+	// Allowed reports whether the caller may act for the tenant, e.g. by
+	// checking the authenticated user's memberships; a failure answers 403
+	Allowed func(c *gin.Context, tenant string) bool
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant ID
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant ID set by TenantMiddleware
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// TenantMiddleware extracts the tenant ID from the tenant header or the
+// request's subdomain, validates it and stores it on the request context
+// for TenantFromContext. It is sent in meta.tenant and used as the feature
+// rollout key unless the response defaults set another tenant.
+//
+// Requests without a tenant get 400 MISSING_HEADER, malformed IDs 400
+// INVALID_INPUT and tenants the caller may not act for 403 FORBIDDEN.
+func TenantMiddleware(opts TenantOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = TenantHeader
+	}
+	baseDomain := "." + strings.Trim(strings.ToLower(opts.BaseDomain), ".")
+
+	return func(c *gin.Context) {
+		tenant := strings.TrimSpace(c.GetHeader(opts.Header))
+		if opts.BaseDomain != "" {
+			if sub := tenantSubdomain(c.Request.Host, baseDomain); sub != "" {
+				if tenant != "" && !strings.EqualFold(tenant, sub) {
+					ErrorResponse(c, Forbidden(fmt.Sprintf("Header %s does not match the tenant of the host", opts.Header)).
+						WithDetails("tenant", tenant))
+					c.Abort()
+					return
+				}
+				tenant = sub
+			}
+		}
+
+		if tenant == "" {
+			if opts.Optional {
+				c.Next()
+				return
+			}
+			ErrorResponse(c, MissingHeader(opts.Header))
+			c.Abort()
+			return
+		}
+		if !tenantIDPattern.MatchString(tenant) {
+			ErrorResponse(c, InvalidInput(opts.Header, "must be 1-63 letters, digits, hyphens or underscores"))
+			c.Abort()
+			return
+		}
+		if opts.Allowed != nil && !opts.Allowed(c, tenant) {
+			ErrorResponse(c, Forbidden(fmt.Sprintf("Access to tenant '%s' is not permitted", tenant)).
+				WithDetails("tenant", tenant))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(ContextWithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
+
+// tenantSubdomain returns the single label in front of baseDomain, so
+// acme.example.com yields "acme" but example.com and a.b.example.com do not
+func tenantSubdomain(host, baseDomain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	sub, ok := strings.CutSuffix(host, baseDomain)
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
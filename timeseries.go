@@ -0,0 +1,205 @@
+package responseutils
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeSeriesLayoutParam is the query parameter choosing the series layout
+const TimeSeriesLayoutParam = "layout"
+
+// Series layouts. Columnar sends each series as parallel timestamp and value
+// arrays, which is smaller and compresses far better than a list of objects.
+const (
+	LayoutPoints   = "points"
+	LayoutColumnar = "columnar"
+)
+
+// Aggregations applied when downsampling
+const (
+	AggregationAvg   = "avg"
+	AggregationSum   = "sum"
+	AggregationMin   = "min"
+	AggregationMax   = "max"
+	AggregationCount = "count"
+	AggregationLast  = "last"
+)
+
+// Resolution is the interval between points, encoded in JSON in compact
+// form such as "30s", "5m", "1h" or "1d"
+type Resolution time.Duration
+
+// String returns the compact form of the resolution
+func (r Resolution) String() string {
+	d := time.Duration(r)
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if d >= unit.size && d%unit.size == 0 {
+			return strconv.FormatInt(int64(d/unit.size), 10) + unit.suffix
+		}
+	}
+	return d.String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r Resolution) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// TimeSeriesPoint is a single observation; a nil Value marks a gap
+// @Description Time series point structure
+type TimeSeriesPoint struct {
+	Time  time.Time `json:"t" example:"2024-06-01T12:00:00Z"`
+	Value *float64  `json:"v" example:"42.5"`
+}
+
+// Series is a named time series. Points is set in the points layout and
+// Columns in the columnar layout.
+// @Description Time series structure
+type Series struct {
+	Name    string            `json:"name" example:"cpu_usage"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Unit    string            `json:"unit,omitempty" example:"percent"`
+	Points  []TimeSeriesPoint `json:"points,omitempty"`
+	Columns *SeriesColumns    `json:"columns,omitempty"`
+}
+
+// SeriesColumns holds a series as parallel arrays of Unix millisecond
+// timestamps and values
+// @Description Columnar time series structure
+type SeriesColumns struct {
+	Timestamps []int64    `json:"timestamps"`
+	Values     []*float64 `json:"values"`
+}
+
+// DownsamplingHint tells clients how the returned resolution relates to the
+// stored data, so charts can request a finer or coarser resolution
+// @Description Downsampling hint structure
+type DownsamplingHint struct {
+	// Applied reports whether points were aggregated from SourceResolution
+	Applied              bool         `json:"applied" example:"true"`
+	SourceResolution     Resolution   `json:"source_resolution,omitempty" swaggertype:"string" example:"10s"`
+	MaxPoints            int          `json:"max_points,omitempty" example:"1000"`
+	AvailableResolutions []Resolution `json:"available_resolutions,omitempty" swaggertype:"array,string"`
+}
+
+// TimeSeriesResponse is a metrics or analytics query result
+// @Description Time series response structure
+type TimeSeriesResponse struct {
+	Success      bool              `json:"success" example:"true"`
+	Series       []Series          `json:"series"`
+	From         time.Time         `json:"from" example:"2024-06-01T00:00:00Z"`
+	To           time.Time         `json:"to" example:"2024-06-02T00:00:00Z"`
+	Resolution   Resolution        `json:"resolution" swaggertype:"string" example:"5m"`
+	Aggregation  string            `json:"aggregation,omitempty" example:"avg"`
+	Layout       string            `json:"layout" example:"points"`
+	Downsampling *DownsamplingHint `json:"downsampling,omitempty"`
+	NextCursor   string            `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNC0wNi0wMlQwMDowMDowMFoifQ"`
+}
+
+// TimeSeriesResultsResponse sends a 200 OK time series result, converting
+// the series to the columnar layout when the request asks for
+// layout=columnar
+func TimeSeriesResultsResponse(c *gin.Context, resp TimeSeriesResponse) {
+	resp.Success = true
+	resp.Layout = LayoutPoints
+	if resp.Series == nil {
+		resp.Series = []Series{}
+	}
+
+	if c.Query(TimeSeriesLayoutParam) == LayoutColumnar {
+		resp.Layout = LayoutColumnar
+		series := make([]Series, len(resp.Series))
+		for i, s := range resp.Series {
+			s.Columns = columnsOf(s.Points)
+			s.Points = nil
+			series[i] = s
+		}
+		resp.Series = series
+	}
+	writeJSON(c, http.StatusOK, resp)
+}
+
+func columnsOf(points []TimeSeriesPoint) *SeriesColumns {
+	cols := &SeriesColumns{
+		Timestamps: make([]int64, len(points)),
+		Values:     make([]*float64, len(points)),
+	}
+	for i, p := range points {
+		cols.Timestamps[i] = p.Time.UnixMilli()
+		cols.Values[i] = p.Value
+	}
+	return cols
+}
+
+// Downsample aggregates points into buckets of the given resolution, keyed
+// by their timestamp truncated to the resolution and skipping gaps. Buckets
+// without values are left out.
+func Downsample(points []TimeSeriesPoint, resolution time.Duration, aggregation string) []TimeSeriesPoint {
+	if resolution <= 0 || len(points) == 0 {
+		return points
+	}
+
+	buckets := map[int64][]float64{}
+	for _, p := range points {
+		if p.Value == nil {
+			continue
+		}
+		key := p.Time.Truncate(resolution).UnixNano()
+		buckets[key] = append(buckets[key], *p.Value)
+	}
+
+	keys := make([]int64, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	out := make([]TimeSeriesPoint, 0, len(keys))
+	for _, k := range keys {
+		v := aggregate(buckets[k], aggregation)
+		out = append(out, TimeSeriesPoint{Time: time.Unix(0, k).UTC(), Value: &v})
+	}
+	return out
+}
+
+func aggregate(values []float64, aggregation string) float64 {
+	switch aggregation {
+	case AggregationSum:
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	case AggregationMin:
+		m := math.Inf(1)
+		for _, v := range values {
+			m = math.Min(m, v)
+		}
+		return m
+	case AggregationMax:
+		m := math.Inf(-1)
+		for _, v := range values {
+			m = math.Max(m, v)
+		}
+		return m
+	case AggregationCount:
+		return float64(len(values))
+	case AggregationLast:
+		return values[len(values)-1]
+	default:
+		return aggregate(values, AggregationSum) / float64(len(values))
+	}
+}
+
+// SeriesValue returns a pointer to v, for building points
+func SeriesValue(v float64) *float64 {
+	return &v
+}
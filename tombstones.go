@@ -0,0 +1,115 @@
+package responseutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tombstonePolicyKey = "responseutils.tombstone_policy"
+
+// SoftDeletable is implemented by resources that are soft-deleted rather than
+// removed. DeletedAt returns the zero time for live resources.
+type SoftDeletable interface {
+	DeletedAt() time.Time
+}
+
+// TombstonePolicy controls how list responses show soft-deleted items
+type TombstonePolicy int
+
+const (
+	// TombstonesOmit drops soft-deleted items from the list
+	TombstonesOmit TombstonePolicy = iota + 1
+	// TombstonesMark keeps soft-deleted items, adding "deleted": true and "deleted_at"
+	TombstonesMark
+)
+
+// SetTombstonePolicy sets how ListResponseWithPagination treats items
+// implementing SoftDeletable for this request. Without a policy items are
+// sent as-is.
+func SetTombstonePolicy(c *gin.Context, p TombstonePolicy) {
+	c.Set(tombstonePolicyKey, p)
+}
+
+// ResourceGone creates a 410 error for a soft-deleted resource, distinct
+// from the 404 sent for resources that never existed
+func ResourceGone(resourceID string, deletedAt time.Time) *ResponseError {
+	return NewResponseError(
+		ErrCodeResourceGone,
+		fmt.Sprintf("Resource %s has been deleted", resourceID),
+		http.StatusGone,
+	).WithDetails("id", resourceID).
+		WithDetails("deleted_at", deletedAt.UTC())
+}
+
+// GoneResponse sends a 410 Gone error for a soft-deleted resource
+func GoneResponse(c *gin.Context, resourceID string, deletedAt time.Time) {
+	ErrorResponse(c, ResourceGone(resourceID, deletedAt))
+}
+
+// applyTombstonePolicy applies the request's tombstone policy to list data
+func applyTombstonePolicy(c *gin.Context, data interface{}) interface{} {
+	v, _ := c.Get(tombstonePolicyKey)
+	policy, _ := v.(TombstonePolicy)
+	if policy == 0 {
+		return data
+	}
+
+	list := reflect.ValueOf(data)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return data
+	}
+
+	items := make([]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i).Interface()
+		sd, ok := item.(SoftDeletable)
+		if !ok || sd.DeletedAt().IsZero() {
+			items = append(items, item)
+			continue
+		}
+		if policy == TombstonesMark {
+			items = append(items, tombstone{item: item, deletedAt: sd.DeletedAt()})
+		}
+	}
+	return items
+}
+
+// tombstone marshals a soft-deleted item with deleted and deleted_at members
+type tombstone struct {
+	item      interface{}
+	deletedAt time.Time
+}
+
+// MarshalJSON implements json.Marshaler
+func (t tombstone) MarshalJSON() ([]byte, error) {
+	data, err := CurrentEncoder().Marshal(t.item)
+	if err != nil {
+		return nil, err
+	}
+	marker, err := json.Marshal(t.deletedAt.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' {
+		// Not an object, so there is nowhere to add the marker
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"deleted":true,"deleted_at":`)
+	buf.Write(marker)
+	if inner := bytes.TrimSpace(data[1 : len(data)-1]); len(inner) > 0 {
+		buf.WriteByte(',')
+		buf.Write(inner)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+package responseutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadedFile describes a successfully stored upload
+// @Description Uploaded file structure
+type UploadedFile struct {
+	ID          string `json:"id" example:"file_123"`
+	Filename    string `json:"filename" example:"report.pdf"`
+	Size        int64  `json:"size" example:"10240"`
+	ContentType string `json:"content_type" example:"application/pdf"`
+	Checksum    string `json:"checksum" example:"sha256:9f86d081884c7d65..."`
+}
+
+// UploadFileError describes why a single file of an upload failed
+type UploadFileError struct {
+	Filename string `json:"filename" example:"huge.iso"`
+	Code     string `json:"code" example:"PAYLOAD_TOO_LARGE"`
+	Message  string `json:"message" example:"File exceeds the size limit"`
+}
+
+// UploadResult represents the outcome of a multi-file upload
+// @Description Upload result structure
+type UploadResult struct {
+	Files  []UploadedFile    `json:"files"`
+	Failed []UploadFileError `json:"failed,omitempty"`
+}
+
+// DescribeUpload reads an uploaded file to compute its size, SHA-256
+// checksum and content type. The content type is taken from the part header,
+// falling back to sniffing the content.
+func DescribeUpload(id string, fh *multipart.FileHeader) (UploadedFile, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return UploadedFile{}, err
+	}
+	head = head[:n]
+
+	h := sha256.New()
+	h.Write(head)
+	rest, err := io.Copy(h, f)
+	if err != nil {
+		return UploadedFile{}, err
+	}
+
+	contentType := fh.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(head)
+	}
+
+	return UploadedFile{
+		ID:          id,
+		Filename:    fh.Filename,
+		Size:        int64(n) + rest,
+		ContentType: contentType,
+		Checksum:    "sha256:" + hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// NewUploadFileError converts an error for a single file into its per-file detail
+func NewUploadFileError(filename string, err error) UploadFileError {
+	_, body := errorBody(err)
+	code, _ := body["code"].(string)
+	message, _ := body["message"].(string)
+	return UploadFileError{Filename: filename, Code: code, Message: message}
+}
+
+// UploadResponse sends the outcome of an upload: 201 when every file was
+// stored, 207 Multi-Status when only some were, and a 400 UPLOAD_FAILED
+// error listing the per-file errors when none were
+func UploadResponse(c *gin.Context, files []UploadedFile, failed []UploadFileError) {
+	if files == nil {
+		files = []UploadedFile{}
+	}
+
+	switch {
+	case len(failed) == 0:
+		CreatedResponse(c, UploadResult{Files: files}, "Files uploaded successfully")
+	case len(files) > 0:
+		SuccessResponse(c, http.StatusMultiStatus, UploadResult{Files: files, Failed: failed}, "Some files could not be uploaded")
+	default:
+		ErrorResponse(c, NewResponseError(
+			ErrCodeUploadFailed,
+			"No file could be uploaded",
+			http.StatusBadRequest,
+		).WithDetails("files", failed))
+	}
+}
+
+// MultipartError maps multipart parsing errors to a standardized error:
+// missing files to MISSING_FILE, oversized bodies to PAYLOAD_TOO_LARGE and
+// anything else to INVALID_MULTIPART
+func MultipartError(err error) *ResponseError {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return PayloadTooLarge(maxBytesErr.Limit)
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		return NewResponseError(ErrCodePayloadTooLarge, "Multipart form exceeds the memory limit", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, http.ErrMissingFile):
+		return NewResponseError(ErrCodeMissingFile, "Required file is missing from the upload", http.StatusBadRequest)
+	case errors.Is(err, http.ErrNotMultipart):
+		return NewResponseError(ErrCodeInvalidMultipart, "Request is not a multipart form", http.StatusBadRequest)
+	case errors.Is(err, http.ErrMissingBoundary):
+		return NewResponseError(ErrCodeInvalidMultipart, "Multipart boundary is missing", http.StatusBadRequest)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return NewResponseError(ErrCodeInvalidMultipart, "Multipart body is truncated", http.StatusBadRequest)
+	}
+	return NewResponseError(ErrCodeInvalidMultipart, "Malformed multipart request", http.StatusBadRequest).
+		WithDetails("error", err.Error())
+}
+
+// isMultipartError reports whether err is one of the well-known multipart parse errors
+func isMultipartError(err error) bool {
+	return errors.Is(err, multipart.ErrMessageTooLarge) ||
+		errors.Is(err, http.ErrMissingFile) ||
+		errors.Is(err, http.ErrNotMultipart) ||
+		errors.Is(err, http.ErrMissingBoundary)
+}
@@ -0,0 +1,70 @@
+package responseutils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const usageProviderKey = "responseutils.usage_provider"
+
+// Usage describes a metered customer's consumption in the current billing period
+// @Description API usage structure
+type Usage struct {
+	Used        int64     `json:"calls_used" example:"4200"`
+	Limit       int64     `json:"calls_limit,omitempty" example:"10000"`
+	Remaining   int64     `json:"calls_remaining" example:"5800"`
+	PeriodStart time.Time `json:"period_start,omitzero"`
+	PeriodEnd   time.Time `json:"period_end,omitzero"`
+}
+
+// UsageProvider returns the usage of the customer making a request
+type UsageProvider interface {
+	Usage(c *gin.Context) (*Usage, error)
+}
+
+// UsageProviderFunc adapts a function to the UsageProvider interface
+type UsageProviderFunc func(c *gin.Context) (*Usage, error)
+
+// Usage implements UsageProvider
+func (f UsageProviderFunc) Usage(c *gin.Context) (*Usage, error) {
+	return f(c)
+}
+
+// UsageMiddleware adds the customer's usage from p to every successful
+// response, as meta.usage and X-Usage-* headers
+func UsageMiddleware(p UsageProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(usageProviderKey, p)
+		c.Next()
+	}
+}
+
+// applyUsage adds the request's usage to a success envelope. The provider is
+// queried at write time so usage recorded by the handler is included.
+func applyUsage(c *gin.Context, e *Envelope) {
+	v, ok := c.Get(usageProviderKey)
+	if !ok || !e.Success() {
+		return
+	}
+
+	u, err := v.(UsageProvider).Usage(c)
+	if err != nil {
+		debugPrintf("usage provider failed on %s: %v", c.FullPath(), err)
+		return
+	}
+	if u == nil {
+		return
+	}
+
+	c.Header("X-Usage-Used", strconv.FormatInt(u.Used, 10))
+	c.Header("X-Usage-Remaining", strconv.FormatInt(u.Remaining, 10))
+	if u.Limit > 0 {
+		c.Header("X-Usage-Limit", strconv.FormatInt(u.Limit, 10))
+	}
+	if !u.PeriodEnd.IsZero() {
+		c.Header("X-Usage-Period-End", u.PeriodEnd.UTC().Format(time.RFC3339))
+	}
+	e.SetMeta("usage", u)
+}
@@ -0,0 +1,64 @@
+package responseutils
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ValidationPolicy controls the status of validation failures
+type ValidationPolicy int32
+
+const (
+	// ValidationBadRequest sends every validation failure as 400 Bad Request;
+	// this is the default
+	ValidationBadRequest ValidationPolicy = iota
+	// ValidationUnprocessable sends semantic validation failures
+	// (VALIDATION_ERROR) as 422 Unprocessable Entity, keeping 400 for
+	// syntactic errors such as malformed bodies (INVALID_BODY)
+	ValidationUnprocessable
+)
+
+var validationPolicy atomic.Int32
+
+// SetValidationPolicy sets the policy applied to validation failures, by
+// ValidationError, the binding helpers and the ErrValidation sentinel alike.
+// The VALIDATION_ERROR registration is updated to match.
+func SetValidationPolicy(p ValidationPolicy) {
+	validationPolicy.Store(int32(p))
+	RegisterErrorCode(ErrCodeValidation, p.StatusCode(), "Input validation failed")
+}
+
+// CurrentValidationPolicy returns the policy applied to validation failures
+func CurrentValidationPolicy() ValidationPolicy {
+	return ValidationPolicy(validationPolicy.Load())
+}
+
+// StatusCode returns the status validation failures are sent with
+func (p ValidationPolicy) StatusCode() int {
+	if p == ValidationUnprocessable {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// String returns the name of the policy
+func (p ValidationPolicy) String() string {
+	if p == ValidationUnprocessable {
+		return "unprocessable"
+	}
+	return "bad_request"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p ValidationPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// validationStatus returns the status an error is sent with: validation
+// failures created with the default 400 follow the validation policy
+func validationStatus(e *ResponseError) int {
+	if e.Code == ErrCodeValidation && e.StatusCode == http.StatusBadRequest {
+		return CurrentValidationPolicy().StatusCode()
+	}
+	return e.StatusCode
+}
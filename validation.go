@@ -0,0 +1,87 @@
+package responseutils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Number is satisfied by the integer and floating point types
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// InvalidEnumValue creates a 400 error for a value outside an enumeration,
+// listing the permitted values in details
+func InvalidEnumValue(field, got string, allowed []string) *ResponseError {
+	if allowed == nil {
+		allowed = []string{}
+	}
+
+	return NewResponseError(
+		ErrCodeInvalidEnumValue,
+		fmt.Sprintf("Invalid value '%s' for field '%s': must be one of %s", got, field, strings.Join(allowed, ", ")),
+		http.StatusBadRequest,
+	).WithDetails("field", field).
+		WithDetails("value", got).
+		WithDetails("allowed", allowed)
+}
+
+// ParseEnum returns value as a T when it is one of the allowed values,
+// otherwise the InvalidEnumValue error for field
+func ParseEnum[T ~string](field, value string, allowed ...T) (T, *ResponseError) {
+	for _, a := range allowed {
+		if string(a) == value {
+			return a, nil
+		}
+	}
+
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+	var zero T
+	return zero, InvalidEnumValue(field, value, names)
+}
+
+// OutOfRange creates a 400 error for a number outside [min, max], with
+// machine-readable min, max and got details for slider-style inputs
+func OutOfRange[N Number](field string, min, max, got N) *ResponseError {
+	return NewResponseError(
+		ErrCodeOutOfRange,
+		fmt.Sprintf("Value %v for field '%s' must be between %v and %v", got, field, min, max),
+		http.StatusBadRequest,
+	).WithDetails("field", field).
+		WithDetails("min", min).
+		WithDetails("max", max).
+		WithDetails("got", got)
+}
+
+// InvalidDateRange creates a 400 error for a date range whose start is after its end
+func InvalidDateRange(from, to time.Time) *ResponseError {
+	return NewResponseError(
+		ErrCodeInvalidDateRange,
+		"The start of the date range must not be after its end",
+		http.StatusBadRequest,
+	).WithDetails("from", from.UTC()).
+		WithDetails("to", to.UTC())
+}
+
+// CheckRange returns the OutOfRange error when got is outside [min, max], otherwise nil
+func CheckRange[N Number](field string, min, max, got N) *ResponseError {
+	if got < min || got > max {
+		return OutOfRange(field, min, max, got)
+	}
+	return nil
+}
+
+// CheckDateRange returns the InvalidDateRange error when from is after to, otherwise nil
+func CheckDateRange(from, to time.Time) *ResponseError {
+	if from.After(to) {
+		return InvalidDateRange(from, to)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package responseutils
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionPath is the conventional route for the version handler
+const VersionPath = "/version"
+
+// BuildInfo describes the running build of a service
+// @Description Build information structure
+type BuildInfo struct {
+	Version   string `json:"version" example:"v1.4.2"`
+	Commit    string `json:"commit,omitempty" example:"9f1c2ab"`
+	BuildTime string `json:"build_time,omitempty" example:"2024-05-01T12:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.5"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// String returns the version, so BuildInfo renders as plain text on MinimalMode routes
+func (b BuildInfo) String() string {
+	return b.Version
+}
+
+// ReadBuildInfo returns the build information embedded in the binary by the
+// Go toolchain: module version, VCS revision, VCS time and Go version
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version()}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Version = bi.Main.Version
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.BuildTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// VersionResponse sends build information in the standard envelope. Empty
+// fields are filled from ReadBuildInfo, so values injected with -ldflags
+// take precedence over the toolchain's.
+func VersionResponse(c *gin.Context, info BuildInfo) {
+	OKResponse(c, mergeBuildInfo(info, ReadBuildInfo()), "Version retrieved successfully")
+}
+
+// VersionHandler returns a handler sending VersionResponse for the given build information
+func VersionHandler(info BuildInfo) gin.HandlerFunc {
+	merged := mergeBuildInfo(info, ReadBuildInfo())
+	return func(c *gin.Context) {
+		OKResponse(c, merged, "Version retrieved successfully")
+	}
+}
+
+func mergeBuildInfo(info, fallback BuildInfo) BuildInfo {
+	if info.Version == "" {
+		info.Version = fallback.Version
+	}
+	if info.Commit == "" {
+		info.Commit = fallback.Commit
+		info.Modified = info.Modified || fallback.Modified
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = fallback.BuildTime
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = fallback.GoVersion
+	}
+	return info
+}
@@ -0,0 +1,195 @@
+package responseutils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default webhook signature headers
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+// DefaultWebhookTolerance is how far a webhook's timestamp may be from the
+// current time
+const DefaultWebhookTolerance = 5 * time.Minute
+
+const webhookPayloadKey = "responseutils.webhook_payload"
+
+// WebhookOptions configure VerifyWebhooks
+type WebhookOptions struct {
+	// Secrets are the shared HMAC secrets; during a rotation list both the
+	// new and the old secret
+	Secrets [][]byte
+	// SignatureHeader carries "sha256=<hex>" signatures, several separated
+	// by commas; defaults to X-Webhook-Signature
+	SignatureHeader string
+	// TimestampHeader carries the Unix time the webhook was signed at;
+	// defaults to X-Webhook-Timestamp
+	TimestampHeader string
+	// Tolerance is how far the timestamp may be from the current time;
+	// defaults to DefaultWebhookTolerance
+	Tolerance time.Duration
+	// Replay rejects webhooks delivered more than once within the tolerance
+	// with 409 REQUEST_REPLAYED
+	Replay NonceStore
+	// MaxBodySize limits the signed body; defaults to 1 MiB
+	MaxBodySize int64
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// VerifyWebhooks returns middleware verifying HMAC-SHA256 signatures on
+// inbound webhooks. The signature covers "<timestamp>.<body>", so an old
+// delivery cannot be re-sent with a fresh timestamp.
+//
+// Verified bodies are available from WebhookPayload, and the request body
+// can still be bound as usual. Failures answer 401 with
+// MISSING_WEBHOOK_SIGNATURE, INVALID_WEBHOOK_SIGNATURE or
+// WEBHOOK_SIGNATURE_EXPIRED, and repeated deliveries 409 REQUEST_REPLAYED.
+func VerifyWebhooks(opts WebhookOptions) gin.HandlerFunc {
+	if opts.SignatureHeader == "" {
+		opts.SignatureHeader = WebhookSignatureHeader
+	}
+	if opts.TimestampHeader == "" {
+		opts.TimestampHeader = WebhookTimestampHeader
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultWebhookTolerance
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return func(c *gin.Context) {
+		body, err := verifyWebhook(c, opts)
+		if err != nil {
+			ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(webhookPayloadKey, body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// WebhookPayload returns the body verified by VerifyWebhooks
+func WebhookPayload(c *gin.Context) ([]byte, bool) {
+	v, ok := c.Get(webhookPayloadKey)
+	if !ok {
+		return nil, false
+	}
+	body, ok := v.([]byte)
+	return body, ok
+}
+
+// SignWebhook returns the signature header value for a webhook body signed
+// at timestamp, for sending webhooks and for tests
+func SignWebhook(secret []byte, timestamp time.Time, body []byte) string {
+	return "sha256=" + hex.EncodeToString(webhookMAC(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+func verifyWebhook(c *gin.Context, opts WebhookOptions) ([]byte, error) {
+	signatures := c.GetHeader(opts.SignatureHeader)
+	timestamp := c.GetHeader(opts.TimestampHeader)
+	if signatures == "" {
+		return nil, MissingWebhookSignature(opts.SignatureHeader)
+	}
+	if timestamp == "" {
+		return nil, MissingWebhookSignature(opts.TimestampHeader)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, InvalidWebhookSignature("timestamp is not a Unix time")
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := opts.Now().Sub(signedAt); skew > opts.Tolerance || skew < -opts.Tolerance {
+		return nil, WebhookSignatureExpired(signedAt, opts.Tolerance)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, PayloadTooLarge(maxBytesErr.Limit)
+		}
+		return nil, InvalidWebhookSignature("body could not be read")
+	}
+
+	var matched []byte
+	for _, sig := range strings.Split(signatures, ",") {
+		hexSig, ok := strings.CutPrefix(strings.TrimSpace(sig), "sha256=")
+		if !ok {
+			continue
+		}
+		got, err := hex.DecodeString(hexSig)
+		if err != nil {
+			continue
+		}
+		for _, secret := range opts.Secrets {
+			if hmac.Equal(got, webhookMAC(secret, timestamp, body)) {
+				matched = got
+			}
+		}
+	}
+	if matched == nil {
+		return nil, InvalidWebhookSignature("signature does not match")
+	}
+
+	if opts.Replay != nil {
+		fresh, err := opts.Replay.Remember(c.Request.Context(), "webhook:"+hex.EncodeToString(matched), 2*opts.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return nil, RequestReplayed()
+		}
+	}
+	return body, nil
+}
+
+func webhookMAC(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func MissingWebhookSignature(header string) *ResponseError {
+	return NewResponseError(
+		ErrCodeMissingWebhookSignature,
+		fmt.Sprintf("Missing webhook signature header: %s", header),
+		http.StatusUnauthorized,
+	).WithDetails("header", header)
+}
+
+func InvalidWebhookSignature(reason string) *ResponseError {
+	return NewResponseError(ErrCodeInvalidWebhookSignature, "Invalid webhook signature: "+reason, http.StatusUnauthorized)
+}
+
+func WebhookSignatureExpired(signedAt time.Time, tolerance time.Duration) *ResponseError {
+	return NewResponseError(
+		ErrCodeWebhookSignatureExpired,
+		"The webhook timestamp is outside the accepted tolerance",
+		http.StatusUnauthorized,
+	).WithDetails("signed_at", signedAt.UTC()).
+		WithDetails("tolerance_seconds", int(tolerance.Seconds()))
+}
@@ -0,0 +1,195 @@
+package responseutils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const writtenEnvelopeKey = "responseutils.written_envelope"
+
+const jsonContentType = "application/json; charset=utf-8"
+
+// PreEncoded is JSON already serialized by the caller, e.g. a cached fragment.
+// It is written into the envelope as-is instead of being encoded as a string.
+type PreEncoded []byte
+
+// MarshalJSON implements json.Marshaler by returning the encoded bytes
+func (p PreEncoded) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// PreEncodedString wraps a serialized JSON string as PreEncoded
+func PreEncodedString(s string) PreEncoded {
+	return PreEncoded(s)
+}
+
+// writeJSON writes a JSON envelope, see writeTyped
+func writeJSON(c *gin.Context, statusCode int, body interface{}) {
+	writeTyped(c, statusCode, jsonContentType, body)
+}
+
+// writeTyped builds the envelope for a response, runs every envelope step
+// and the response hooks on it, and writes it with the current encoder
+func writeTyped(c *gin.Context, statusCode int, contentType string, body interface{}) {
+	if !envelopeAcceptable(c, contentType) {
+		_, errBody := errorBody(NotAcceptable([]string{"application/json"}))
+		writeEnvelope(c, &Envelope{
+			StatusCode:  http.StatusNotAcceptable,
+			ContentType: jsonContentType,
+			Body:        Response{Success: false, Error: errBody},
+		})
+		return
+	}
+
+	e := &Envelope{StatusCode: statusCode, ContentType: contentType, Body: body, Links: RequestLinks(c)}
+	applyDefaults(c, e)
+	applyClientRef(c, e)
+	applyChannelMessage(c, e)
+	applyUsage(c, e)
+	applyConsistencyToken(c, e)
+	applyFetchMetadata(c, e)
+	applySlowMeta(c, e)
+	applyDeprecatedFields(c, e)
+	err := runResponseHooks(c, e)
+	if err == nil {
+		err = applyPayloadReference(c, e)
+	}
+	if err != nil {
+		statusCode, errBody := errorBody(err)
+		e = &Envelope{StatusCode: statusCode, ContentType: jsonContentType, Body: Response{Success: false, Error: errBody}}
+	}
+
+	applyErrorReference(c, e)
+	applyPreloadLinks(c, e)
+	writeEnvelope(c, e)
+}
+
+// writeEnvelope serializes and writes an envelope without running hooks,
+// dropping the meta and links sections when their features are disabled
+func writeEnvelope(c *gin.Context, e *Envelope) {
+	if len(e.Meta) > 0 && !FeatureEnabled(c, FeatureMeta) {
+		e.Meta = nil
+	}
+	if len(e.Links) > 0 && !FeatureEnabled(c, FeatureLinks) {
+		e.Links = nil
+	}
+
+	applyCatalogCacheScope(c, e)
+	applyProfileLabels(c, e)
+	start := time.Now()
+	data, err := encodeEnvelope(CurrentEncoder(), e)
+	if err == nil {
+		data, err = applyCanonical(c, data)
+	}
+	_, stats := c.Get(responseStatsKey)
+	_, timing := c.Get(timingKey)
+	if stats || timing {
+		c.Set(serializationTimeKey, time.Since(start))
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	writeEncoded(c, e, data)
+}
+
+// writeEncoded writes an envelope's serialized form, checking it against the
+// frozen contract and encrypting it before recording the envelope for
+// WrittenEnvelope
+func writeEncoded(c *gin.Context, e *Envelope, data []byte) {
+	checkContract(c, data)
+
+	contentType := e.ContentType
+	if contentType == "" {
+		contentType = jsonContentType
+	}
+	data, contentType, err := applyEncryption(c, data, contentType)
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Set(writtenEnvelopeKey, e)
+	writeBody(c, e.StatusCode, contentType, data)
+}
+
+// WrittenEnvelope returns the envelope written for this request, for
+// middleware inspecting responses after c.Next
+func WrittenEnvelope(c *gin.Context) (*Envelope, bool) {
+	v, ok := c.Get(writtenEnvelopeKey)
+	if !ok {
+		return nil, false
+	}
+	e, ok := v.(*Envelope)
+	return e, ok
+}
+
+// bodyAllowedForStatus reports whether a response body may be written for a
+// status: never for 1xx, 204 or 304 responses
+func bodyAllowedForStatus(statusCode int) bool {
+	switch {
+	case statusCode >= 100 && statusCode <= 199:
+		return false
+	case statusCode == http.StatusNoContent, statusCode == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// writeBody writes a serialized response, compressing it and adding the
+// Content-Digest, Server-Timing and signature headers on routes that enable
+// them. HEAD requests get the same
+// Content-Type and Content-Length (and any ETag set by the handler) as the
+// equivalent GET, without the body. When the status allows no body only the
+// status and headers are written, with a warning in debug mode if a helper
+// passed a body anyway.
+func writeBody(c *gin.Context, statusCode int, contentType string, data []byte) {
+	bodyAllowed := bodyAllowedForStatus(statusCode)
+	if bodyAllowed {
+		data = applyZstd(c, data)
+		applyContentDigest(c, data)
+	}
+	applyServerTiming(c)
+	signedType := contentType
+	if !bodyAllowed {
+		signedType = ""
+	}
+	if err := applySignature(c, statusCode, signedType); err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if !bodyAllowed {
+		if len(data) > 0 {
+			debugPrintf("dropped %d byte body for status %d on %s", len(data), statusCode, c.FullPath())
+		}
+		c.Status(statusCode)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	if c.Request != nil && c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Length", strconv.Itoa(len(data)))
+		c.Status(statusCode)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Data(statusCode, contentType, data)
+}
+
+// HandleGETAndHEAD registers handlers for both GET and HEAD requests on a
+// path; the writers take care of suppressing the body for HEAD
+func HandleGETAndHEAD(r gin.IRoutes, path string, handlers ...gin.HandlerFunc) {
+	r.GET(path, handlers...)
+	r.HEAD(path, handlers...)
+}
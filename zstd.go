@@ -0,0 +1,212 @@
+package responseutils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+const zstdKey = "responseutils.zstd"
+
+// DefaultZstdMinSize is the body size below which ZstdResponses leaves
+// responses uncompressed
+const DefaultZstdMinSize = 1024
+
+// dczHeader starts every dictionary-compressed zstd (dcz) body, followed by
+// the SHA-256 of the dictionary, see RFC 9842
+var dczHeader = []byte{0x5e, 0x2a, 0x4d, 0x18, 0x20, 0x00, 0x00, 0x00}
+
+var plainZstd = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// ZstdDictionary is a shared compression dictionary, typically trained on a
+// route's envelopes with TrainZstdDictionary
+type ZstdDictionary struct {
+	data []byte
+	hash [32]byte
+	enc  *zstd.Encoder
+}
+
+// NewZstdDictionary creates a dictionary from its raw content
+func NewZstdDictionary(data []byte) (*ZstdDictionary, error) {
+	data = append([]byte(nil), data...)
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDictRaw(0, data))
+	if err != nil {
+		return nil, err
+	}
+	return &ZstdDictionary{data: data, hash: sha256.Sum256(data), enc: enc}, nil
+}
+
+// Hash returns the dictionary's SHA-256 as a structured field byte sequence,
+// the form clients send in Available-Dictionary
+func (d *ZstdDictionary) Hash() string {
+	return ":" + base64.StdEncoding.EncodeToString(d.hash[:]) + ":"
+}
+
+// Handler serves the dictionary with a Use-As-Dictionary header telling
+// browsers to use it for URLs matching match, e.g. "/api/orders*"
+func (d *ZstdDictionary) Handler(match string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Use-As-Dictionary", "match="+strconv.Quote(match))
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Header("ETag", `"`+d.Hash()+`"`)
+		c.Data(http.StatusOK, "application/octet-stream", d.data)
+	}
+}
+
+// ZstdOptions configure ZstdResponses
+type ZstdOptions struct {
+	// Dictionary is offered to clients advertising it in Available-Dictionary
+	// and accepting the "dcz" coding; nil compresses without a dictionary
+	Dictionary *ZstdDictionary
+	// MinSize is the body size below which responses are sent uncompressed;
+	// defaults to DefaultZstdMinSize
+	MinSize int
+}
+
+// ZstdResponses returns middleware compressing the bodies of a route with
+// zstd when the client accepts it. Clients holding the route's dictionary get
+// the much smaller dictionary-compressed "dcz" coding, others plain "zstd".
+func ZstdResponses(opts ZstdOptions) gin.HandlerFunc {
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultZstdMinSize
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if opts.Dictionary != nil {
+			c.Writer.Header().Add("Vary", "Available-Dictionary")
+		}
+		c.Set(zstdKey, opts)
+		c.Next()
+	}
+}
+
+// applyZstd compresses a body on ZstdResponses routes, setting
+// Content-Encoding. It runs before applyContentDigest, whose digest covers the
+// encoded content.
+func applyZstd(c *gin.Context, data []byte) []byte {
+	v, ok := c.Get(zstdKey)
+	if !ok || len(data) < v.(ZstdOptions).MinSize || c.Writer.Header().Get("Content-Encoding") != "" {
+		return data
+	}
+	opts := v.(ZstdOptions)
+	accept := c.GetHeader("Accept-Encoding")
+
+	if d := opts.Dictionary; d != nil && acceptsEncoding(accept, "dcz") && c.GetHeader("Available-Dictionary") == d.Hash() {
+		out := make([]byte, 0, len(dczHeader)+len(d.hash)+len(data)/4)
+		out = append(append(out, dczHeader...), d.hash[:]...)
+		c.Header("Content-Encoding", "dcz")
+		return d.enc.EncodeAll(data, out)
+	}
+	if acceptsEncoding(accept, "zstd") {
+		enc, err := plainZstd()
+		if err != nil {
+			debugPrintf("zstd encoder unavailable: %v", err)
+			return data
+		}
+		c.Header("Content-Encoding", "zstd")
+		return enc.EncodeAll(data, make([]byte, 0, len(data)/4))
+	}
+	return data
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header accepts a coding,
+// by name or through "*", with a non-zero quality
+func acceptsEncoding(header, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, coding) && name != "*" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "q") {
+				q, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+		if strings.EqualFold(name, coding) {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// DefaultZstdDictionarySize is the dictionary size TrainZstdDictionary
+// produces when none is given
+const DefaultZstdDictionarySize = 32 << 10
+
+const dictionarySegment = 16
+
+// TrainZstdDictionary builds a raw content dictionary of at most size bytes
+// from sample response bodies, e.g. RecordedExchange.ResponseBody values. It
+// keeps the byte sequences shared by the most samples, such as envelope keys
+// and repeated field names, with the most common last, where zstd reaches
+// them with the shortest offsets.
+func TrainZstdDictionary(samples [][]byte, size int) []byte {
+	if size <= 0 {
+		size = DefaultZstdDictionarySize
+	}
+
+	// Count how many samples contain each segment
+	freq := map[string]int{}
+	for _, sample := range samples {
+		seen := map[string]bool{}
+		for i := 0; i+dictionarySegment <= len(sample); i += dictionarySegment / 4 {
+			seg := string(sample[i : i+dictionarySegment])
+			if !seen[seg] {
+				seen[seg] = true
+				freq[seg]++
+			}
+		}
+	}
+
+	minFreq := 2
+	if len(samples) < 2 {
+		minFreq = 1
+	}
+	segments := make([]string, 0, len(freq))
+	for seg, n := range freq {
+		if n >= minFreq {
+			segments = append(segments, seg)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		if freq[segments[i]] != freq[segments[j]] {
+			return freq[segments[i]] > freq[segments[j]]
+		}
+		return segments[i] < segments[j]
+	})
+
+	// Pick the most common segments not already covered, then reverse them
+	var picked []string
+	var buf bytes.Buffer
+	for _, seg := range segments {
+		if buf.Len()+len(seg) > size {
+			break
+		}
+		if bytes.Contains(buf.Bytes(), []byte(seg)) {
+			continue
+		}
+		picked = append(picked, seg)
+		buf.WriteString(seg)
+	}
+
+	dict := make([]byte, 0, buf.Len())
+	for i := len(picked) - 1; i >= 0; i-- {
+		dict = append(dict, picked[i]...)
+	}
+	return dict
+}